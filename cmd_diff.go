@@ -0,0 +1,50 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+
+	"github.com/yangbin1322/go-gsmarena/export"
+)
+
+// runDiff diff 子命令：比较两次抓取结果（JSONL 文件、分片清单或快照）
+// 用法: diff [参数] <旧结果> <新结果>
+func runDiff(args []string) error {
+	fs := flag.NewFlagSet("diff", flag.ExitOnError)
+	format := fs.String("format", "text", "输出格式: text / json")
+	out := fs.String("out", "-", "输出文件（- 表示标准输出）")
+	fs.Parse(args)
+
+	if fs.NArg() != 2 {
+		return fmt.Errorf("用法: diff [-format text|json] [-out 文件] <旧结果> <新结果>")
+	}
+	if *format != "text" && *format != "json" {
+		return fmt.Errorf("不支持的输出格式: %q", *format)
+	}
+
+	report, err := export.Diff(export.JSONLSource{Path: fs.Arg(0)}, export.JSONLSource{Path: fs.Arg(1)})
+	if err != nil {
+		return err
+	}
+
+	err = writeOutput(*out, func(w *bufio.Writer) error {
+		if *format == "text" {
+			return report.WriteText(w)
+		}
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(report)
+	})
+	if err != nil {
+		return err
+	}
+
+	if *out != "-" {
+		log.Printf("差异: 新增 %d，移除 %d，变更 %d，未变化 %d -> %s",
+			len(report.Added), len(report.Removed), len(report.Changed), report.Unchanged, *out)
+	}
+	return nil
+}
@@ -0,0 +1,242 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/yangbin1322/go-gsmarena/export"
+	"github.com/yangbin1322/go-gsmarena/sink"
+	"github.com/yangbin1322/go-gsmarena/storage"
+)
+
+// runExport export 子命令：将抓取结果导出为其他格式
+// 用法: export <格式> [参数]
+func runExport(args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("用法: export <csv|tsv|snapshot|elasticsearch> [参数]")
+	}
+
+	switch args[0] {
+	case "csv", "tsv":
+		return exportCSV(args[0], args[1:])
+	case "snapshot":
+		return exportSnapshot(args[1:])
+	case "elasticsearch", "es":
+		return exportElasticsearch(args[1:])
+	default:
+		return fmt.Errorf("不支持的导出格式: %q", args[0])
+	}
+}
+
+// sourceFlags 导出数据源参数（JSONL 文件或 BoltDB 记录）
+type sourceFlags struct {
+	in string
+	db string
+}
+
+// register 注册数据源参数
+func (f *sourceFlags) register(fs *flag.FlagSet) {
+	fs.StringVar(&f.in, "in", OutputFile, "JSONL 结果文件（支持 .gz / .zst 分片及 .manifest.json 清单）")
+	fs.StringVar(&f.db, "db", "", "从 BoltDB 数据库读取记录（指定时忽略 -in）")
+}
+
+// open 打开数据源，返回的 close 用于释放数据库连接
+func (f *sourceFlags) open() (export.Source, func(), error) {
+	if f.db == "" {
+		return export.JSONLSource{Path: f.in}, func() {}, nil
+	}
+	store, err := storage.NewBoltStorage(f.db, BucketName)
+	if err != nil {
+		return nil, nil, fmt.Errorf("打开数据库失败: %w", err)
+	}
+	return export.RecordSource{Store: store}, func() { store.Close() }, nil
+}
+
+// exportCSV 导出 CSV/TSV，每个规格参数一列
+func exportCSV(format string, args []string) error {
+	fs := flag.NewFlagSet("export "+format, flag.ExitOnError)
+	var src sourceFlags
+	src.register(fs)
+	out := fs.String("out", "results."+format, "输出文件（- 表示标准输出）")
+	columns := fs.String("columns", "", "列选择及顺序，逗号分隔，每项为 字段 或 表头=字段（默认全部字段）")
+	multi := fs.String("multi", string(export.MultiJoin), "多值字段展开方式: join / first / split")
+	sep := fs.String("sep", "; ", "join 模式下的多值分隔符")
+	fs.Parse(args)
+
+	opts := export.CSVOptions{
+		Comma:     ',',
+		Multi:     export.MultiValueMode(*multi),
+		Separator: *sep,
+	}
+	if format == "tsv" {
+		opts.Comma = '\t'
+	}
+	if *columns != "" {
+		opts.Columns = strings.Split(*columns, ",")
+	}
+
+	source, closeSource, err := src.open()
+	if err != nil {
+		return err
+	}
+	defer closeSource()
+
+	return writeOutput(*out, func(w *bufio.Writer) error {
+		stats, err := export.WriteCSV(w, source, opts)
+		if err != nil {
+			return err
+		}
+		log.Printf("导出完成: %d 行，%d 列 -> %s", stats.Rows, stats.Columns, *out)
+		return nil
+	})
+}
+
+// exportSnapshot 导出去重快照：每个设备只保留最新的一条记录
+// 输出格式与 -sink 相同（jsonl / csv / sqlite / parquet / stdout）
+func exportSnapshot(args []string) error {
+	fs := flag.NewFlagSet("export snapshot", flag.ExitOnError)
+	var src sourceFlags
+	src.register(fs)
+	format := fs.String("format", "jsonl", "输出格式: jsonl / csv / sqlite / parquet / stdout")
+	out := fs.String("out", "", "输出文件（默认 snapshot-<日期>.<格式>），可附带输出参数，如 snapshot.parquet?compression=zstd")
+	fs.Parse(args)
+
+	spec := *format
+	if *format != "stdout" {
+		path := *out
+		if path == "" {
+			switch *format {
+			case "parquet":
+				// Parquet 输出自带运行时间戳
+				path = "snapshot.parquet"
+			case "sqlite":
+				path = "snapshot-" + time.Now().Format("20060102") + ".db"
+			default:
+				path = "snapshot-" + time.Now().Format("20060102") + "." + *format
+			}
+		}
+
+		// 快照需要是干净的文件，jsonl / csv / sqlite 输出会在已有文件上追加或合并，先删除旧文件
+		file, _, _ := strings.Cut(path, "?")
+		if err := os.Remove(file); err == nil {
+			log.Printf("覆盖已有的快照文件: %s", file)
+		} else if !errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("删除旧快照失败: %w", err)
+		}
+		spec += ":" + path
+	}
+
+	source, closeSource, err := src.open()
+	if err != nil {
+		return err
+	}
+	defer closeSource()
+
+	dst, err := sink.Open(spec)
+	if err != nil {
+		return err
+	}
+	stats, err := export.Snapshot(source, dst)
+	if closeErr := dst.Close(); err == nil && closeErr != nil {
+		err = fmt.Errorf("关闭快照输出失败: %w", closeErr)
+	}
+	if err != nil {
+		return err
+	}
+
+	log.Printf("快照完成: %d 条记录 -> %d 台设备（去除 %d 条重复记录，涉及 %d 台设备）-> %s",
+		stats.Records, stats.Devices, stats.Duplicates, stats.Repeated, spec)
+	return nil
+}
+
+// exportElasticsearch 导出 Elasticsearch / OpenSearch _bulk 格式的 NDJSON 及索引模板
+// 指定 -push 时直接推送到集群，不生成文件
+func exportElasticsearch(args []string) error {
+	fs := flag.NewFlagSet("export elasticsearch", flag.ExitOnError)
+	var src sourceFlags
+	src.register(fs)
+	index := fs.String("index", "phones", "索引名称（模板匹配 <索引名称>*）")
+	out := fs.String("out", "phones.ndjson", "bulk 文件（- 表示标准输出）")
+	template := fs.String("template", "phones-template.json", "索引模板文件（为空时不生成）")
+	push := fs.String("push", "", "直接推送到集群，如 http://localhost:9200")
+	user := fs.String("user", "", "Basic 认证用户名")
+	passwordEnv := fs.String("password-env", "ES_PASSWORD", "保存 Basic 认证密码的环境变量")
+	batch := fs.Int("batch", export.DefaultBulkBatchSize, "推送时每个 _bulk 请求的文档数")
+	fs.Parse(args)
+
+	source, closeSource, err := src.open()
+	if err != nil {
+		return err
+	}
+	defer closeSource()
+
+	if *push != "" {
+		client := &export.BulkClient{URL: *push, Username: *user, Password: os.Getenv(*passwordEnv)}
+		ctx := context.Background()
+		if err := client.PutTemplate(ctx, *index, export.IndexTemplate(*index)); err != nil {
+			return err
+		}
+		stats, err := client.Push(ctx, source, *index, *batch)
+		if err != nil {
+			return err
+		}
+		log.Printf("推送完成: %d 个文档写入成功，%d 个失败（%d 个请求）-> %s/%s",
+			stats.Indexed, stats.Failed, stats.Requests, *push, *index)
+		if stats.Failed > 0 {
+			return fmt.Errorf("%d 个文档写入失败", stats.Failed)
+		}
+		return nil
+	}
+
+	if *template != "" {
+		data, err := json.MarshalIndent(export.IndexTemplate(*index), "", "  ")
+		if err != nil {
+			return fmt.Errorf("索引模板序列化失败: %w", err)
+		}
+		if err := os.WriteFile(*template, append(data, '\n'), 0644); err != nil {
+			return fmt.Errorf("写入索引模板失败: %w", err)
+		}
+	}
+
+	return writeOutput(*out, func(w *bufio.Writer) error {
+		count, err := export.WriteBulk(w, source, *index)
+		if err != nil {
+			return err
+		}
+		log.Printf("导出完成: %d 个文档 -> %s", count, *out)
+		return nil
+	})
+}
+
+// writeOutput 打开输出文件（"-" 为标准输出）并在 fn 完成后刷新关闭
+func writeOutput(path string, fn func(w *bufio.Writer) error) error {
+	file := os.Stdout
+	if path != "-" {
+		f, err := os.Create(path)
+		if err != nil {
+			return fmt.Errorf("创建输出文件失败: %w", err)
+		}
+		defer f.Close()
+		file = f
+	}
+
+	w := bufio.NewWriter(file)
+	if err := fn(w); err != nil {
+		return err
+	}
+	if err := w.Flush(); err != nil {
+		return fmt.Errorf("写入输出文件失败: %w", err)
+	}
+	if path != "-" {
+		return file.Sync()
+	}
+	return nil
+}
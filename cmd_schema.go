@@ -0,0 +1,67 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+
+	"github.com/yangbin1322/go-gsmarena/export"
+	"github.com/yangbin1322/go-gsmarena/schema"
+)
+
+// runSchema schema 子命令：输出 Phone 记录的 JSON Schema
+func runSchema(args []string) error {
+	fs := flag.NewFlagSet("schema", flag.ExitOnError)
+	out := fs.String("out", "-", "输出文件（- 表示标准输出）")
+	fs.Parse(args)
+
+	data, err := json.MarshalIndent(schema.Phone(), "", "  ")
+	if err != nil {
+		return fmt.Errorf("Schema 序列化失败: %w", err)
+	}
+	return writeOutput(*out, func(w *bufio.Writer) error {
+		_, err := w.Write(append(data, '\n'))
+		return err
+	})
+}
+
+// runValidate validate 子命令：按 JSON Schema 校验结果文件，存在不合规记录时返回错误
+func runValidate(args []string) error {
+	fs := flag.NewFlagSet("validate", flag.ExitOnError)
+	in := fs.String("in", OutputFile, "JSONL 结果文件（支持 .gz / .zst 分片及 .manifest.json 清单）")
+	limit := fs.Int("max", 50, "最多输出的问题条数（0 表示不限制）")
+	fs.Parse(args)
+
+	s := schema.Phone()
+	records, invalid, reported := 0, 0, 0
+	err := export.JSONLSource{Path: *in}.EachLine(func(file string, lineNo int, line []byte) error {
+		records++
+		violations, err := schema.ValidateJSON(s, line)
+		if err != nil {
+			violations = []schema.Violation{{Path: "$", Message: err.Error()}}
+		}
+		if len(violations) == 0 {
+			return nil
+		}
+		invalid++
+		for _, v := range violations {
+			if *limit > 0 && reported >= *limit {
+				break
+			}
+			reported++
+			fmt.Printf("%s:%d: %s\n", file, lineNo, v)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	log.Printf("校验完成: %d 条记录，%d 条不符合 schema_version=%d", records, invalid, s.Properties["schema_version"].Const)
+	if invalid > 0 {
+		return fmt.Errorf("%d 条记录未通过校验", invalid)
+	}
+	return nil
+}
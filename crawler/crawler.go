@@ -0,0 +1,391 @@
+// Package crawler 实现 GSMArena 的抓取流程：品牌列表、手机链接、手机详情以及搜索发现
+package crawler
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net"
+	"net/http"
+	"net/http/cookiejar"
+	"strings"
+	"time"
+
+	"github.com/gocolly/colly/v2"
+	"github.com/yangbin1322/go-gsmarena/proxy"
+	"github.com/yangbin1322/go-gsmarena/storage"
+)
+
+// 默认配置
+const (
+	// 品牌列表页地址
+	MakersURL = "https://www.gsmarena.com/makers.php3"
+
+	// 默认 Colly 并发数
+	DefaultParallelism = 5
+
+	// 默认随机延迟范围
+	DefaultMinDelay = 500 * time.Millisecond
+	DefaultMaxDelay = 1000 * time.Millisecond
+
+	// 默认请求超时时间
+	DefaultRequestTimeout = 15 * time.Second
+
+	// 默认中断后等待在途请求完成的最长时间
+	DefaultDrainTimeout = 30 * time.Second
+
+	// 默认 User-Agent
+	DefaultUserAgent = "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36"
+)
+
+// throttledRetryDelay 暂无可用代理（proxy.ErrRefreshThrottled）时重试前的等待时间
+const throttledRetryDelay = 2 * time.Second
+
+// ErrDeferred PhoneHandler 返回该错误（或包装了它的错误）表示记录已交给输出，但要到输出关闭时才真正落盘
+// 该记录计入成功（DetailStats.Saved）；存储实现 storage.RecordStore 时保留待导出标记，由输出落盘后确认
+var ErrDeferred = errors.New("记录尚未落盘")
+
+// PhoneHandler 处理解析完成的手机数据，返回 error 时该记录计入失败（DetailStats.Failed）
+// 存储未实现 storage.RecordStore 时，该 URL 不会被标记为已访问，下次运行会重新抓取；
+// 实现 storage.RecordStore 时（如 BoltStorage），记录已与已访问标记一同提交，保留在待导出队列中，
+// 需通过 Crawler.RecoverPending（或 sink.Multi.Recover）重新交给 handler
+type PhoneHandler func(phone Phone) error
+
+// Crawler GSMArena 爬虫
+// 通过 Option 配置存储、代理和限速参数，各阶段以方法形式提供
+type Crawler struct {
+	storage        storage.Storage           // 持久化存储（URL 去重）
+	proxies        *proxy.Manager            // 代理管理器（为 nil 时直连）
+	onPhone        PhoneHandler              // 手机数据处理回调
+	parallelism    int                       // 并发数
+	minDelay       time.Duration             // 随机延迟
+	maxDelay       time.Duration             // 固定延迟
+	requestTimeout time.Duration             // 请求超时时间
+	drainTimeout   time.Duration             // 中断后等待在途请求的最长时间
+	userAgent      string                    // User-Agent
+	sessionKey     SessionKeyFunc            // 品牌列表页的代理会话（为 nil 时按请求轮换）
+	sessionJars    map[string]http.CookieJar // 会话 -> Cookie Jar（只在 FetchPhoneLinks 中按品牌顺序访问）
+}
+
+// SessionKeyFunc 返回品牌列表页所属的代理会话
+// 同一会话的请求使用同一代理（直到该代理失败）和同一个 Cookie Jar；返回空字符串时按请求轮换代理
+type SessionKeyFunc func(brand Brand) string
+
+// SessionPerBrand 每个品牌一个代理会话：同一品牌的列表页由同一 IP 连续翻页
+func SessionPerBrand(brand Brand) string {
+	return "brand:" + brand.Name
+}
+
+// Option 爬虫配置项
+type Option func(*Crawler)
+
+// WithStorage 设置持久化存储，默认使用内存存储
+func WithStorage(s storage.Storage) Option {
+	return func(c *Crawler) {
+		c.storage = s
+	}
+}
+
+// WithProxyManager 设置代理管理器，不设置时直连目标站点
+func WithProxyManager(pm *proxy.Manager) Option {
+	return func(c *Crawler) {
+		c.proxies = pm
+	}
+}
+
+// WithPhoneHandler 设置手机数据处理回调（如写入输出文件）
+func WithPhoneHandler(fn PhoneHandler) Option {
+	return func(c *Crawler) {
+		c.onPhone = fn
+	}
+}
+
+// WithParallelism 设置并发请求数
+func WithParallelism(n int) Option {
+	return func(c *Crawler) {
+		if n > 0 {
+			c.parallelism = n
+		}
+	}
+}
+
+// WithDelay 设置请求延迟范围
+func WithDelay(min, max time.Duration) Option {
+	return func(c *Crawler) {
+		c.minDelay = min
+		c.maxDelay = max
+	}
+}
+
+// WithRequestTimeout 设置请求超时时间
+func WithRequestTimeout(d time.Duration) Option {
+	return func(c *Crawler) {
+		if d > 0 {
+			c.requestTimeout = d
+		}
+	}
+}
+
+// WithDrainTimeout 设置中断后等待在途请求完成的最长时间
+func WithDrainTimeout(d time.Duration) Option {
+	return func(c *Crawler) {
+		if d > 0 {
+			c.drainTimeout = d
+		}
+	}
+}
+
+// WithStickySessions 启用品牌列表页的代理会话粘滞（需要设置代理管理器），如 WithStickySessions(SessionPerBrand)
+// 手机详情页不受影响，仍按请求轮换代理
+func WithStickySessions(fn SessionKeyFunc) Option {
+	return func(c *Crawler) {
+		c.sessionKey = fn
+	}
+}
+
+// WithUserAgent 设置 User-Agent
+func WithUserAgent(ua string) Option {
+	return func(c *Crawler) {
+		if ua != "" {
+			c.userAgent = ua
+		}
+	}
+}
+
+// New 创建爬虫实例
+func New(opts ...Option) *Crawler {
+	c := &Crawler{
+		parallelism:    DefaultParallelism,
+		minDelay:       DefaultMinDelay,
+		maxDelay:       DefaultMaxDelay,
+		requestTimeout: DefaultRequestTimeout,
+		drainTimeout:   DefaultDrainTimeout,
+		userAgent:      DefaultUserAgent,
+		sessionJars:    make(map[string]http.CookieJar),
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	if c.storage == nil {
+		c.storage = storage.NewMemoryStorage()
+	}
+	return c
+}
+
+// newCollector 创建并配置 Colly 爬虫实例
+// ctx 取消后不再重试失败的请求；在途 HTTP 请求使用独立的 context，
+// 以便中断时先等待其完成，超时后再通过返回的 abort 取消。
+// stop 用于取消所在阶段的 ctx（如代理 API 预算用完），原因可通过 context.Cause 取得
+func (c *Crawler) newCollector(ctx context.Context, stop context.CancelCauseFunc) (*colly.Collector, context.CancelFunc, error) {
+	inflightCtx, abort := context.WithCancel(context.WithoutCancel(ctx))
+
+	collector := colly.NewCollector(
+		// 限制爬取域名
+		colly.AllowedDomains("www.gsmarena.com", "gsmarena.com"),
+		// 启用异步模式
+		colly.Async(true),
+		// 在途请求的 context
+		colly.StdlibContext(inflightCtx),
+	)
+
+	// 配置 HTTP 传输层（设置超时和代理）
+	collector.WithTransport(&http.Transport{
+		// 设置连接超时
+		DialContext: (&net.Dialer{
+			Timeout:   c.requestTimeout,
+			KeepAlive: 30 * time.Second,
+		}).DialContext,
+		// 最大空闲连接
+		MaxIdleConns:        100,
+		MaxIdleConnsPerHost: 10,
+		// 响应头超时
+		ResponseHeaderTimeout: c.requestTimeout,
+		// TLS 握手超时
+		TLSHandshakeTimeout: 10 * time.Second,
+	})
+
+	// 设置代理
+	if c.proxies != nil {
+		collector.SetProxyFunc(c.proxies.GetProxy)
+	}
+
+	// 设置限速规则
+	err := collector.Limit(&colly.LimitRule{
+		DomainGlob:  "*gsmarena.com*",
+		Parallelism: c.parallelism,
+		RandomDelay: c.minDelay,
+		Delay:       c.maxDelay,
+	})
+	if err != nil {
+		abort()
+		return nil, nil, err
+	}
+
+	// 设置 User-Agent
+	collector.OnRequest(func(r *colly.Request) {
+		r.Headers.Set("User-Agent", c.userAgent)
+		r.Headers.Set("Accept", "text/html,application/xhtml+xml,application/xml;q=0.9,image/webp,*/*;q=0.8")
+		r.Headers.Set("Accept-Language", "en-US,en;q=0.9")
+	})
+
+	c.setupErrorHandler(ctx, stop, collector)
+	return collector, abort, nil
+}
+
+// useSession 让 collector 的请求使用品牌对应的代理会话和 Cookie Jar，未启用会话时为空操作
+func (c *Crawler) useSession(collector *colly.Collector, brand Brand) {
+	if c.sessionKey == nil || c.proxies == nil {
+		return
+	}
+	key := c.sessionKey(brand)
+	if key == "" {
+		return
+	}
+
+	collector.SetProxyFunc(c.proxies.SessionProxy(key))
+
+	// 同一会话共用 Cookie Jar（会话可能跨多个品牌的 collector）
+	jar, ok := c.sessionJars[key]
+	if !ok {
+		jar, _ = cookiejar.New(nil)
+		c.sessionJars[key] = jar
+	}
+	collector.SetCookieJar(jar)
+}
+
+// sessionBrands 统计每个代理会话包含的品牌数，未启用会话时返回 nil
+func (c *Crawler) sessionBrands(brands []Brand) map[string]int {
+	if c.sessionKey == nil || c.proxies == nil {
+		return nil
+	}
+	counts := make(map[string]int)
+	for _, brand := range brands {
+		if key := c.sessionKey(brand); key != "" {
+			counts[key]++
+		}
+	}
+	return counts
+}
+
+// finishSession 品牌遍历完成（或跳过）后调用，会话的最后一个品牌完成时结束会话，释放绑定的代理和 Cookie Jar
+func (c *Crawler) finishSession(remaining map[string]int, brand Brand) {
+	if remaining == nil {
+		return
+	}
+	key := c.sessionKey(brand)
+	if _, ok := remaining[key]; !ok {
+		return
+	}
+	remaining[key]--
+	if remaining[key] > 0 {
+		return
+	}
+	delete(remaining, key)
+	c.endSession(key)
+}
+
+// endSession 结束代理会话
+func (c *Crawler) endSession(key string) {
+	c.proxies.EndSession(key)
+	delete(c.sessionJars, key)
+}
+
+// setupErrorHandler 设置通用的错误处理和重试逻辑
+// 代理 API 预算用完时重试也无法获得代理，调用 stop 结束所在阶段，未完成的 URL 留待下次运行
+func (c *Crawler) setupErrorHandler(ctx context.Context, stop context.CancelCauseFunc, collector *colly.Collector) {
+	// OnRequest: 请求发送前
+	collector.OnRequest(func(r *colly.Request) {
+		log.Printf("[请求] %s", r.URL)
+	})
+
+	// OnError: 请求失败处理
+	collector.OnError(func(r *colly.Response, err error) {
+		statusCode := r.StatusCode
+		requestURL := r.Request.URL.String()
+		proxyURL := ""
+		if c.proxies != nil {
+			proxyURL, _ = c.proxies.Release(r.Request.URL)
+		}
+
+		log.Printf("[错误] URL=%s, StatusCode=%d, Error=%v, Proxy=%s",
+			requestURL, statusCode, err, proxy.Redact(proxyURL))
+
+		shouldRetry := false
+		failure := proxy.FailureError
+
+		switch {
+		case errors.Is(err, proxy.ErrRefreshThrottled):
+			// 暂时没有可分配的代理（冷却中或补货受限），稍等后重试
+			log.Printf("[代理] 暂无可用代理，%v 后重试: %s", throttledRetryDelay, requestURL)
+			shouldRetry = sleep(ctx, throttledRetryDelay)
+
+		case errors.Is(err, proxy.ErrBudgetExhausted):
+			log.Printf("[预算] 代理 API 调用预算已用完，停止当前阶段: %s", requestURL)
+			stop(err)
+
+		case statusCode == 0:
+			log.Printf("[网络错误] StatusCode=0，需要重试: %v", err)
+			shouldRetry = true
+			if err != nil && (strings.Contains(err.Error(), "connection refused") ||
+				strings.Contains(err.Error(), "proxyconnect")) {
+				// 无法连接代理本身，属于硬失败
+				failure = proxy.FailureHard
+			}
+
+		case statusCode == 404:
+			log.Printf("[404] 页面不存在，跳过: %s", requestURL)
+			_ = c.storage.MarkVisited(requestURL)
+
+		case statusCode == 403 || statusCode == 429 || statusCode == 503:
+			log.Printf("[风控] 状态码 %d，代理冷却并重试", statusCode)
+			shouldRetry = true
+			failure = proxy.FailureBan
+
+		case err != nil && (strings.Contains(err.Error(), "timeout") ||
+			strings.Contains(err.Error(), "connection refused") ||
+			strings.Contains(err.Error(), "EOF")):
+			log.Printf("[超时/连接失败] 代理冷却并重试")
+			shouldRetry = true
+			if strings.Contains(err.Error(), "connection refused") {
+				failure = proxy.FailureHard
+			}
+
+		default:
+			log.Printf("[其他错误] 不重试: %v", err)
+		}
+
+		// 已收到中断信号时不再重试，留待下次运行
+		if shouldRetry && ctx.Err() != nil {
+			log.Printf("[中断] 放弃重试: %s", requestURL)
+			shouldRetry = false
+		}
+
+		if shouldRetry {
+			if proxyURL != "" {
+				c.proxies.ReportFailure(proxyURL, failure)
+			}
+			if err := r.Request.Retry(); err != nil {
+				log.Printf("[重试失败] %s: %v", requestURL, err)
+			} else {
+				log.Printf("[已重试] %s", requestURL)
+				return
+			}
+		}
+		releaseRequest(r.Ctx)
+	})
+
+	// OnResponse: 响应成功
+	collector.OnResponse(func(r *colly.Response) {
+		log.Printf("[响应] %s (状态码: %d)", r.Request.URL, r.StatusCode)
+		if c.proxies != nil {
+			if proxyURL, start := c.proxies.Release(r.Request.URL); proxyURL != "" {
+				c.proxies.ReportSuccess(proxyURL, time.Since(start))
+			}
+		}
+	})
+
+	// OnScraped: 请求处理完成（所有 OnHTML 回调之后）
+	collector.OnScraped(func(r *colly.Response) {
+		releaseRequest(r.Ctx)
+	})
+}
@@ -0,0 +1,127 @@
+package crawler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/yangbin1322/go-gsmarena/proxy"
+)
+
+// emptyProvider 不返回任何代理的付费来源
+type emptyProvider struct{}
+
+func (emptyProvider) Name() string { return "empty" }
+
+func (emptyProvider) Fetch(ctx context.Context) ([]proxy.Lease, error) { return nil, nil }
+
+func TestBudgetExhaustedStopsPhase(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		sources []proxy.Source
+	}{
+		{"付费来源", []proxy.Source{{Provider: emptyProvider{}}}},
+		// 免费来源返回空列表时，付费来源被拒绝仍应报告预算用完（与 main.go 的配置相同）
+		{"免费来源为空加付费来源", []proxy.Source{
+			{Provider: proxy.EnvProvider{Var: "GSMARENA_TEST_NO_PROXIES"}, Free: true, Priority: 1},
+			{Provider: emptyProvider{}},
+		}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			testBudgetExhaustedStopsPhase(t, tc.sources)
+		})
+	}
+}
+
+func testBudgetExhaustedStopsPhase(t *testing.T, sources []proxy.Source) {
+	// 初始化时用掉唯一一次调用，之后 GetProxy 返回 ErrBudgetExhausted
+	pm := proxy.NewManager("", 1,
+		proxy.WithProviders(sources...),
+		proxy.WithBudget(proxy.Budget{MaxPerDay: 1}))
+	c := New(WithProxyManager(pm), WithDelay(0, 0), WithParallelism(2))
+
+	links := make([]string, 10)
+	for i := range links {
+		links[i] = fmt.Sprintf("https://www.gsmarena.com/test_phone-%d.php", i)
+	}
+
+	done := make(chan struct{})
+	var stats *DetailStats
+	var err error
+	go func() {
+		stats, err = c.FetchPhoneDetails(context.Background(), links)
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(10 * time.Second):
+		t.Fatal("预算用完后阶段没有结束（请求仍在重试）")
+	}
+
+	if !errors.Is(err, proxy.ErrBudgetExhausted) {
+		t.Fatalf("err = %v，期望 ErrBudgetExhausted", err)
+	}
+	if stats.Saved != 0 || stats.Remaining != len(links) {
+		t.Errorf("统计 = %+v，期望全部计入未完成", stats)
+	}
+}
+
+func TestStickySessionsEndAfterLastBrand(t *testing.T) {
+	// 测试服务器同时作为 HTTP 代理和品牌列表页（请求不会到达真实站点），记录每个品牌请求时的会话数
+	var pm *proxy.Manager
+	var mu sync.Mutex
+	sessions := map[string]int{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		sessions[r.URL.Path] = pm.Stats().Sessions
+		mu.Unlock()
+		fmt.Fprintf(w, `<div class="makers"><ul><li><a href="%s.php">Phone</a></li></ul></div>`, strings.TrimSuffix(r.URL.Path, ".php"))
+	}))
+	defer srv.Close()
+
+	t.Setenv("GSMARENA_TEST_SESSION_PROXY", srv.Listener.Addr().String())
+	pm = proxy.NewManager("", 1, proxy.WithProviders(proxy.Source{Provider: proxy.EnvProvider{Var: "GSMARENA_TEST_SESSION_PROXY"}}))
+
+	// a 和 c 共用一个会话，b 单独一个会话
+	brands := []Brand{
+		{Name: "A", URL: "http://www.gsmarena.com/a-phones-1.php", DevicesCount: 1},
+		{Name: "B", URL: "http://www.gsmarena.com/b-phones-2.php", DevicesCount: 1},
+		{Name: "C", URL: "http://www.gsmarena.com/c-phones-3.php", DevicesCount: 1},
+	}
+	group := func(brand Brand) string {
+		if brand.Name == "B" {
+			return "b"
+		}
+		return "ac"
+	}
+	c := New(WithProxyManager(pm), WithStickySessions(group), WithDelay(0, 0))
+	links, err := c.FetchPhoneLinks(context.Background(), brands)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(links) != 3 {
+		t.Errorf("获取 %d 个链接，期望 3", len(links))
+	}
+
+	// 请求 c 时 b 的会话已结束，ac 会话持续到 c 完成
+	want := map[string]int{"/a-phones-1.php": 1, "/b-phones-2.php": 2, "/c-phones-3.php": 1}
+	mu.Lock()
+	defer mu.Unlock()
+	for path, n := range want {
+		if sessions[path] != n {
+			t.Errorf("请求 %s 时会话数 = %d，期望 %d", path, sessions[path], n)
+		}
+	}
+	if n := pm.Stats().Sessions; n != 0 {
+		t.Errorf("阶段结束后会话数 = %d，期望 0", n)
+	}
+	if len(c.sessionJars) != 0 {
+		t.Errorf("阶段结束后仍保留 %d 个 Cookie Jar", len(c.sessionJars))
+	}
+}
@@ -0,0 +1,101 @@
+package crawler
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/gocolly/colly/v2"
+)
+
+// releaseKey colly.Context 中保存请求完成回调的键
+const releaseKey = "release"
+
+// dispatcher 限制已派发但未完成的请求数量
+// Colly 异步模式下 Visit 会立即为每个 URL 启动协程并在限速器上排队，
+// 一次性派发全部链接会导致中断时无法停止排队中的请求，因此按槽位逐个派发
+type dispatcher struct {
+	collector *colly.Collector
+	slots     chan struct{}
+}
+
+// newDispatcher 创建派发器，size 为同时在途的最大请求数
+func newDispatcher(collector *colly.Collector, size int) *dispatcher {
+	if size < 1 {
+		size = 1
+	}
+	return &dispatcher{
+		collector: collector,
+		slots:     make(chan struct{}, size),
+	}
+}
+
+// visit 等待空闲槽位后派发请求
+// ctx 取消时返回 false，表示已停止派发
+func (d *dispatcher) visit(ctx context.Context, url string) bool {
+	select {
+	case d.slots <- struct{}{}:
+	case <-ctx.Done():
+		return false
+	}
+
+	// 请求的最终结果（成功、放弃重试）通过 releaseRequest 归还槽位，
+	// 重试会复用同一个 colly.Context，因此用 sync.Once 保证只归还一次
+	var once sync.Once
+	reqCtx := colly.NewContext()
+	reqCtx.Put(releaseKey, func() {
+		once.Do(func() { <-d.slots })
+	})
+
+	if err := d.collector.Request("GET", url, nil, reqCtx, nil); err != nil {
+		log.Printf("访问失败: %s: %v", url, err)
+		releaseRequest(reqCtx)
+	}
+	return true
+}
+
+// releaseRequest 请求最终完成时归还派发槽位（未经 dispatcher 派发的请求为空操作）
+func releaseRequest(ctx *colly.Context) {
+	if release, ok := ctx.GetAny(releaseKey).(func()); ok {
+		release()
+	}
+}
+
+// wait 等待 collector 中的请求全部完成
+// ctx 取消后最多再等待 drainTimeout，超时后调用 abort 中断在途请求
+func (c *Crawler) wait(ctx context.Context, collector *colly.Collector, abort context.CancelFunc) {
+	done := make(chan struct{})
+	go func() {
+		collector.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return
+	case <-ctx.Done():
+	}
+
+	log.Printf("[中断] 停止派发新请求，等待在途请求完成（最长 %s）...", c.drainTimeout)
+	select {
+	case <-done:
+		log.Println("[中断] 在途请求已全部完成")
+	case <-time.After(c.drainTimeout):
+		log.Println("[中断] 等待超时，取消剩余在途请求")
+		abort()
+		<-done
+	}
+}
+
+// sleep 可被 ctx 中断的延迟，返回 false 表示 ctx 已取消
+func sleep(ctx context.Context, d time.Duration) bool {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
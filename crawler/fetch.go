@@ -0,0 +1,388 @@
+package crawler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/gocolly/colly/v2"
+	"github.com/yangbin1322/go-gsmarena/storage"
+)
+
+// FetchBrandList 阶段1: 获取所有品牌列表
+func (c *Crawler) FetchBrandList(ctx context.Context) ([]Brand, error) {
+	brands := make([]Brand, 0)
+	var brandsMutex sync.Mutex
+
+	ctx, stop := context.WithCancelCause(ctx)
+	defer stop(nil)
+	collector, abort, err := c.newCollector(ctx, stop)
+	if err != nil {
+		return nil, fmt.Errorf("创建 collector 失败: %w", err)
+	}
+	defer abort()
+
+	// 解析品牌列表页
+	collector.OnHTML(".st-text a", func(e *colly.HTMLElement) {
+		brand := parseBrand(e)
+		log.Printf("[品牌] %s | %d devices | %s", brand.Name, brand.DevicesCount, brand.URL)
+
+		brandsMutex.Lock()
+		brands = append(brands, brand)
+		brandsMutex.Unlock()
+	})
+
+	// 访问品牌列表页
+	if err := collector.Visit(MakersURL); err != nil {
+		return nil, fmt.Errorf("访问品牌列表页失败: %w", err)
+	}
+	c.wait(ctx, collector, abort)
+
+	if ctx.Err() != nil {
+		return nil, fmt.Errorf("阶段 1 被中断: %w", context.Cause(ctx))
+	}
+	if len(brands) == 0 {
+		return nil, errors.New("品牌列表为空")
+	}
+	return brands, nil
+}
+
+// FetchPhoneLinks 阶段2: 获取所有手机链接（使用URL构造方式翻页）
+// ctx 取消（或代理 API 预算用完）时停止遍历剩余品牌，返回已获取的链接和包装了取消原因的错误
+func (c *Crawler) FetchPhoneLinks(ctx context.Context, brands []Brand) ([]string, error) {
+	ctx, stop := context.WithCancelCause(ctx)
+	defer stop(nil)
+
+	// 使用 map 进行快速去重
+	phoneLinkSet := make(map[string]bool)
+	var linksMutex sync.Mutex
+
+	// 用于统计每个品牌实际获取的链接数
+	brandLinkCount := make(map[string]int)
+	var brandCountMutex sync.Mutex
+
+	// 已完成的品牌数量（用于中断时报告进度）
+	brandsDone := 0
+
+	// 代理会话在其最后一个品牌遍历完成后结束；中断时结束剩余的会话
+	sessions := c.sessionBrands(brands)
+	defer func() {
+		for key := range sessions {
+			c.endSession(key)
+		}
+	}()
+
+	// ⭐ 为每个品牌创建独立的 collector，确保同步
+	for i, brand := range brands {
+		if ctx.Err() != nil {
+			break
+		}
+
+		collector, abort, err := c.newCollector(ctx, stop)
+		if err != nil {
+			return nil, fmt.Errorf("创建 collector 失败: %w", err)
+		}
+
+		// 启用会话粘滞时，同一品牌的列表页使用同一代理和 Cookie
+		c.useSession(collector, brand)
+
+		// 当前品牌名称（用于闭包）
+		currentBrandName := brand.Name
+
+		// 解析手机列表页
+		collector.OnHTML(".makers", func(e *colly.HTMLElement) {
+			linkCount := 0
+
+			// 提取手机详情页链接
+			for _, phoneURL := range parsePhoneLinks(e) {
+				linksMutex.Lock()
+				if !phoneLinkSet[phoneURL] {
+					phoneLinkSet[phoneURL] = true
+					linkCount++
+					log.Printf("[发现] 手机链接 #%d: %s (品牌: %s)",
+						len(phoneLinkSet), phoneURL, currentBrandName)
+				}
+				linksMutex.Unlock()
+			}
+
+			// 更新品牌链接计数
+			if linkCount > 0 {
+				brandCountMutex.Lock()
+				brandLinkCount[currentBrandName] += linkCount
+				brandCountMutex.Unlock()
+
+				log.Printf("[本页统计] %s: 本页发现 %d 个新设备", currentBrandName, linkCount)
+			}
+		})
+
+		// 计算需要访问的总页数（每页50个设备）
+		totalPages := (brand.DevicesCount + 49) / 50
+		if totalPages == 0 {
+			totalPages = 1
+		}
+
+		log.Printf("[品牌开始] %d/%d: %s (预计 %d 页，官网显示 %d 台设备)",
+			i+1, len(brands), brand.Name, totalPages, brand.DevicesCount)
+
+		// 从品牌URL中提取品牌标识和ID
+		brandSlug, brandID := extractBrandInfo(brand.URL)
+
+		if brandSlug == "" || brandID == "" {
+			log.Printf("[警告] 无法解析品牌URL: %s，跳过", brand.URL)
+			abort()
+			brandsDone++
+			c.finishSession(sessions, brand)
+			continue
+		}
+
+		// 访问所有页面
+		for page := 1; page <= totalPages && ctx.Err() == nil; page++ {
+			var pageURL string
+
+			if page == 1 {
+				pageURL = brand.URL
+			} else {
+				pageURL = fmt.Sprintf("https://www.gsmarena.com/%s-phones-f-%s-0-p%d.php",
+					brandSlug, brandID, page)
+			}
+
+			log.Printf("  [第 %d/%d 页] %s", page, totalPages, pageURL)
+
+			if err := collector.Visit(pageURL); err != nil {
+				log.Printf("  [错误] 访问失败: %v", err)
+			}
+
+			// 短暂延迟
+			sleep(ctx, 200*time.Millisecond)
+		}
+
+		// ⭐ 关键：等待当前品牌的所有请求完成
+		c.wait(ctx, collector, abort)
+		abort()
+		if ctx.Err() != nil {
+			log.Printf("[中断] %s: 品牌未遍历完成", brand.Name)
+			break
+		}
+		brandsDone++
+		c.finishSession(sessions, brand)
+
+		// 输出当前品牌的统计
+		brandCountMutex.Lock()
+		actualCount := brandLinkCount[brand.Name]
+		brandCountMutex.Unlock()
+
+		completionRate := 0.0
+		if brand.DevicesCount > 0 {
+			completionRate = float64(actualCount) / float64(brand.DevicesCount) * 100
+		}
+
+		status := "✓"
+		if actualCount < brand.DevicesCount {
+			status = "⚠"
+		}
+
+		log.Printf("[品牌完成] %s: 获取 %d/%d 个设备链接 (%.1f%%) %s",
+			brand.Name,
+			actualCount,
+			brand.DevicesCount,
+			completionRate,
+			status)
+
+		if actualCount < brand.DevicesCount {
+			missing := brand.DevicesCount - actualCount
+			log.Printf("  [注意] 缺少 %d 个设备链接", missing)
+		}
+
+		// ⭐ 品牌之间添加延迟，避免请求过快
+		sleep(ctx, 500*time.Millisecond)
+	}
+
+	// 最终统计
+	log.Printf("\n========== 链接获取总结 ==========")
+	totalExpected := 0
+	totalActual := len(phoneLinkSet)
+
+	for _, brand := range brands {
+		totalExpected += brand.DevicesCount
+	}
+
+	log.Printf("预期总数: %d", totalExpected)
+	log.Printf("实际获取: %d", totalActual)
+	if totalExpected > 0 {
+		log.Printf("完成率: %.2f%%", float64(totalActual)/float64(totalExpected)*100)
+	}
+	log.Printf("================================\n")
+
+	// 转换 map 为 slice
+	phoneLinks := make([]string, 0, len(phoneLinkSet))
+	for link := range phoneLinkSet {
+		phoneLinks = append(phoneLinks, link)
+	}
+
+	if ctx.Err() != nil {
+		return phoneLinks, fmt.Errorf("阶段 2 被中断（已完成 %d/%d 个品牌）: %w",
+			brandsDone, len(brands), context.Cause(ctx))
+	}
+	if totalActual == 0 {
+		return nil, errors.New("未获取到任何手机链接")
+	}
+
+	return phoneLinks, nil
+}
+
+// FetchPhoneDetails 阶段3: 获取所有手机详情
+// 解析结果通过 WithPhoneHandler 设置的回调输出，处理成功后标记为已访问
+// ctx 取消（或代理 API 预算用完）时停止派发，等待在途请求完成后返回统计和包装了取消原因的错误，
+// 未完成的链接计入 Remaining
+func (c *Crawler) FetchPhoneDetails(ctx context.Context, phoneLinks []string) (*DetailStats, error) {
+	stats := &DetailStats{Total: len(phoneLinks)}
+	var statsMutex sync.Mutex
+
+	ctx, stop := context.WithCancelCause(ctx)
+	defer stop(nil)
+	collector, abort, err := c.newCollector(ctx, stop)
+	if err != nil {
+		return nil, fmt.Errorf("创建 collector 失败: %w", err)
+	}
+	defer abort()
+
+	// 在途请求数量为并发数的 2 倍，保证限速器始终有请求可发
+	d := newDispatcher(collector, c.parallelism*2)
+
+	// 解析手机详情页
+	collector.OnHTML("#specs-list", func(e *colly.HTMLElement) {
+		phoneURL := e.Request.URL.String()
+
+		// 去重检查
+		if c.storage.IsVisited(phoneURL) {
+			log.Printf("[跳过] 已访问: %s", phoneURL)
+			return
+		}
+
+		phone := parsePhone(e)
+
+		// 保存数据并标记为已访问
+		if err := c.commit(phone); err != nil {
+			log.Printf("[错误] 保存数据失败: %s: %v", phoneURL, err)
+			statsMutex.Lock()
+			stats.Failed++
+			statsMutex.Unlock()
+			return
+		}
+
+		statsMutex.Lock()
+		stats.Saved++
+		statsMutex.Unlock()
+		log.Printf("[成功] 已抓取: %s", phone.ModelName)
+	})
+
+	// 访问所有手机详情页
+	for i, phoneURL := range phoneLinks {
+		if c.storage.IsVisited(phoneURL) {
+			log.Printf("[跳过] 已访问 #%d/%d: %s", i+1, len(phoneLinks), phoneURL)
+			statsMutex.Lock()
+			stats.Skipped++
+			statsMutex.Unlock()
+			continue
+		}
+		log.Printf("[进度] 正在获取手机 %d/%d", i+1, len(phoneLinks))
+		if !d.visit(ctx, phoneURL) {
+			break
+		}
+	}
+
+	c.wait(ctx, collector, abort)
+
+	stats.Remaining = stats.Total - stats.Skipped - stats.Saved - stats.Failed
+	if ctx.Err() != nil {
+		return stats, fmt.Errorf("阶段 3 被中断（剩余 %d 个未完成）: %w", stats.Remaining, context.Cause(ctx))
+	}
+	return stats, nil
+}
+
+// commit 保存手机数据并标记为已访问
+// 存储实现 storage.RecordStore 时，记录与已访问标记在同一事务中提交，随后交给 handler 导出；
+// 导出失败时返回错误，记录保留在待导出队列中，由 RecoverPending 或 sink.Multi.Recover 补齐
+// 否则先调用 handler，成功后再标记已访问（handler 失败时下次运行重新抓取）
+func (c *Crawler) commit(phone Phone) error {
+	records, ok := c.storage.(storage.RecordStore)
+	if !ok {
+		if c.onPhone != nil {
+			if err := c.onPhone(phone); err != nil && !errors.Is(err, ErrDeferred) {
+				return err
+			}
+		}
+		return c.storage.MarkVisited(phone.URL)
+	}
+
+	data, err := json.Marshal(phone)
+	if err != nil {
+		return fmt.Errorf("JSON 序列化失败: %w", err)
+	}
+	if err := records.SaveRecord(phone.URL, data); err != nil {
+		return err
+	}
+
+	if c.onPhone != nil {
+		err := c.onPhone(phone)
+		if errors.Is(err, ErrDeferred) {
+			// 输出落盘后再清除待导出标记
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("导出失败（记录已保存，待补齐）: %w", err)
+		}
+	}
+	return records.AckRecord(phone.URL)
+}
+
+// RecoverPending 将已保存但未导出的记录重新交给 PhoneHandler，处理成功的记录清除待导出标记
+// 存储未实现 storage.RecordStore 或未设置 handler 时不做任何操作；返回补齐的记录数量，
+// handler 再次失败的记录保留在队列中，返回的错误包含失败数量
+// 使用 sink.Multi 作为输出时应改用 sink.Multi.Recover（只补写各输出缺失的记录）
+func (c *Crawler) RecoverPending() (int, error) {
+	records, ok := c.storage.(storage.RecordStore)
+	if !ok || c.onPhone == nil {
+		return 0, nil
+	}
+
+	pending := make(map[string]Phone)
+	err := records.PendingRecords(func(url string, record []byte) error {
+		var phone Phone
+		if err := json.Unmarshal(record, &phone); err != nil {
+			return fmt.Errorf("解析待导出记录失败: %s: %w", url, err)
+		}
+		phone.Migrate()
+		pending[url] = phone
+		return nil
+	})
+	if err != nil {
+		return 0, fmt.Errorf("读取待导出记录失败: %w", err)
+	}
+
+	recovered, failed := 0, 0
+	for url, phone := range pending {
+		err := c.onPhone(phone)
+		if errors.Is(err, ErrDeferred) {
+			recovered++
+			continue
+		}
+		if err != nil {
+			log.Printf("[恢复] 补齐失败: %s: %v", url, err)
+			failed++
+			continue
+		}
+		if err := records.AckRecord(url); err != nil {
+			return recovered, err
+		}
+		recovered++
+	}
+	if failed > 0 {
+		return recovered, fmt.Errorf("%d 条记录补齐失败，保留在待导出队列中", failed)
+	}
+	return recovered, nil
+}
@@ -0,0 +1,83 @@
+package crawler
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/yangbin1322/go-gsmarena/storage"
+)
+
+func TestRecoverPendingAfterRestart(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "crawler.db")
+	phone := Phone{URL: "https://www.gsmarena.com/test_phone-1.php", ModelName: "Phone 1"}
+
+	// 第一次运行：记录已保存，输出失败后进程退出
+	db, err := storage.NewBoltStorage(path, "visited")
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := New(WithStorage(db), WithPhoneHandler(func(Phone) error { return errors.New("磁盘已满") }))
+	if err := c.commit(phone); err == nil {
+		t.Fatal("输出失败时 commit 应返回错误")
+	}
+	if !db.IsVisited(phone.URL) {
+		t.Error("记录已保存时 URL 应标记为已访问")
+	}
+	if err := db.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// 重启后补齐一次，之后队列为空
+	db, err = storage.NewBoltStorage(path, "visited")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	var got []Phone
+	c = New(WithStorage(db), WithPhoneHandler(func(p Phone) error {
+		got = append(got, p)
+		return nil
+	}))
+	for i := range 2 {
+		if _, err := c.RecoverPending(); err != nil {
+			t.Fatalf("第 %d 次补齐: %v", i+1, err)
+		}
+	}
+	if len(got) != 1 || got[0].URL != phone.URL || got[0].ModelName != phone.ModelName {
+		t.Errorf("补齐的记录 = %+v，期望恰好一次 %s", got, phone.URL)
+	}
+}
+
+func TestRecoverPendingKeepsFailed(t *testing.T) {
+	db, err := storage.NewBoltStorage(filepath.Join(t.TempDir(), "crawler.db"), "visited")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	phone := Phone{URL: "https://www.gsmarena.com/test_phone-1.php"}
+	if err := db.SaveRecord(phone.URL, []byte(`{"url":"`+phone.URL+`"}`)); err != nil {
+		t.Fatal(err)
+	}
+
+	// 补齐再次失败时保留在队列中，下次仍会重试
+	fail := true
+	calls := 0
+	c := New(WithStorage(db), WithPhoneHandler(func(Phone) error {
+		calls++
+		if fail {
+			return errors.New("磁盘已满")
+		}
+		return nil
+	}))
+	if n, err := c.RecoverPending(); n != 0 || err == nil {
+		t.Fatalf("RecoverPending = %d, %v，期望 0 和错误", n, err)
+	}
+	fail = false
+	if n, err := c.RecoverPending(); n != 1 || err != nil {
+		t.Fatalf("RecoverPending = %d, %v，期望 1", n, err)
+	}
+	if n, _ := c.RecoverPending(); n != 0 || calls != 2 {
+		t.Errorf("确认后再次补齐 %d 条，共调用 %d 次，期望 0 条、2 次", n, calls)
+	}
+}
@@ -0,0 +1,62 @@
+package crawler
+
+// Brand 品牌数据结构
+type Brand struct {
+	Name         string // 品牌名称
+	URL          string // 品牌页面 URL
+	DevicesCount int    // 设备数量
+}
+
+// SchemaVersion 当前 Phone 记录格式的版本号，字段发生不兼容变化时递增
+// 输出的每条记录都带有 schema_version 字段，JSON Schema 见 schema 包
+const SchemaVersion = 1
+
+// legacySchemaVersion 引入 schema_version 字段之前的记录（该字段缺失）对应的格式版本
+const legacySchemaVersion = 1
+
+// Phone 手机数据结构
+type Phone struct {
+	SchemaVersion int               `json:"schema_version"` // 记录格式版本，见 SchemaVersion
+	ModelName     string            `json:"model_name"`     // 手机型号名称
+	Brand         string            `json:"brand"`          // 品牌
+	ReleaseDate   string            `json:"release_date"`   // 发布日期
+	URL           string            `json:"url"`            // 详情页 URL
+	Specs         map[string]string `json:"specs"`          // 规格参数（键值对）
+	CrawledAt     string            `json:"crawled_at"`     // 抓取时间
+
+	// Sections 按详情页分组（Network、Display、Battery...）保存的规格参数，保留页面顺序
+	// Specs 中不同分组的同名字段（如 Display 和 Battery 的 Type）会互相覆盖，需要完整数据时使用该字段
+	Sections []SpecSection `json:"sections,omitempty"`
+}
+
+// Migrate 读取已保存的记录后调用：补全缺失的格式版本，并将旧版本的记录逐级迁移到当前结构
+// 无法识别的版本（如更新版本写入的记录）保持原值，由调用方决定如何处理
+func (p *Phone) Migrate() {
+	if p.SchemaVersion == 0 {
+		p.SchemaVersion = legacySchemaVersion
+	}
+	// 格式发生不兼容变化时，在这里按 p.SchemaVersion 逐级迁移并更新版本号
+}
+
+// SpecSection 详情页中的一个规格参数分组
+type SpecSection struct {
+	Name   string      `json:"name"`   // 分组名称，如 "Display"
+	Fields []SpecField `json:"fields"` // 分组内的字段（页面顺序）
+}
+
+// SpecField 规格参数字段
+type SpecField struct {
+	Name  string `json:"name"`  // 字段名称，如 "Size"
+	Value string `json:"value"` // 字段值（多行值以换行分隔）
+}
+
+// DetailStats 阶段 3 的执行统计
+type DetailStats struct {
+	Total   int // 待抓取链接总数
+	Skipped int // 已访问而跳过的数量
+	Saved   int // 成功解析并保存的数量
+	Failed  int // 解析成功但保存失败的数量
+
+	// Remaining 未完成的数量（请求失败或因中断未派发），下次运行会继续抓取
+	Remaining int
+}
@@ -0,0 +1,145 @@
+package crawler
+
+import (
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// NormalizedSpecs 从规格参数文本中解析出的数值字段
+// 无法解析的字段为零值，供 Parquet / SQLite / 搜索引擎等需要数值类型的输出使用
+type NormalizedSpecs struct {
+	ReleaseYear       int     `json:"release_year,omitempty"`        // 发布年份（Announced，缺失时取 ReleaseDate）
+	DisplaySizeInches float64 `json:"display_size_inches,omitempty"` // 屏幕尺寸（英寸）
+	DisplayWidthPx    int     `json:"display_width_px,omitempty"`    // 屏幕分辨率宽（像素）
+	DisplayHeightPx   int     `json:"display_height_px,omitempty"`   // 屏幕分辨率高（像素）
+	BatteryMAh        int     `json:"battery_mah,omitempty"`         // 电池容量（mAh）
+	WeightGrams       float64 `json:"weight_grams,omitempty"`        // 重量（克）
+	RAMGB             float64 `json:"ram_gb,omitempty"`              // 最大内存（GB）
+	StorageGB         float64 `json:"storage_gb,omitempty"`          // 最大存储（GB）
+	PriceEUR          float64 `json:"price_eur,omitempty"`           // 价格（欧元）
+	Has5G             bool    `json:"has_5g,omitempty"`              // 是否支持 5G
+}
+
+var (
+	yearRe       = regexp.MustCompile(`\b(19|20)\d{2}\b`)
+	inchesRe     = regexp.MustCompile(`([\d.]+)\s*inches`)
+	resolutionRe = regexp.MustCompile(`(\d+)\s*x\s*(\d+)\s*pixels`)
+	mAhRe        = regexp.MustCompile(`(\d+)\s*mAh`)
+	gramsRe      = regexp.MustCompile(`([\d.]+)\s*g\b`)
+	ramRe        = regexp.MustCompile(`([\d.]+)\s*(GB|MB)\s*RAM`)
+	storageRe    = regexp.MustCompile(`([\d.]+)\s*(TB|GB|MB)\b(\s*RAM)?`)
+	euroRe       = regexp.MustCompile(`(?:€\s*([\d,]+(?:\.\d+)?))|(?:([\d,]+(?:\.\d+)?)\s*EUR)`)
+)
+
+// Normalize 解析手机规格参数中的数值字段
+func Normalize(phone Phone) NormalizedSpecs {
+	specs := phone.Specs
+	var n NormalizedSpecs
+
+	// 发布年份
+	if m := yearRe.FindString(specs["Announced"]); m != "" {
+		n.ReleaseYear, _ = strconv.Atoi(m)
+	} else if m := yearRe.FindString(phone.ReleaseDate); m != "" {
+		n.ReleaseYear, _ = strconv.Atoi(m)
+	}
+
+	// 屏幕
+	if m := inchesRe.FindStringSubmatch(specs["Size"]); m != nil {
+		n.DisplaySizeInches, _ = strconv.ParseFloat(m[1], 64)
+	}
+	if m := resolutionRe.FindStringSubmatch(specs["Resolution"]); m != nil {
+		n.DisplayWidthPx, _ = strconv.Atoi(m[1])
+		n.DisplayHeightPx, _ = strconv.Atoi(m[2])
+	}
+
+	// 电池
+	n.BatteryMAh = batteryMAh(phone)
+
+	// 重量
+	if m := gramsRe.FindStringSubmatch(specs["Weight"]); m != nil {
+		n.WeightGrams, _ = strconv.ParseFloat(m[1], 64)
+	}
+
+	// 内存与存储，如 "128GB 6GB RAM, 256GB 8GB RAM"，取最大值
+	internal := specs["Internal"]
+	for _, m := range ramRe.FindAllStringSubmatch(internal, -1) {
+		n.RAMGB = max(n.RAMGB, toGB(m[1], m[2]))
+	}
+	for _, m := range storageRe.FindAllStringSubmatch(internal, -1) {
+		if m[3] == "" {
+			n.StorageGB = max(n.StorageGB, toGB(m[1], m[2]))
+		}
+	}
+
+	// 价格，如 "About 250 EUR" 或 "$ 799.99 / € 899.00"
+	if m := euroRe.FindStringSubmatch(specs["Price"]); m != nil {
+		value := m[1] + m[2]
+		n.PriceEUR, _ = strconv.ParseFloat(strings.ReplaceAll(value, ",", ""), 64)
+	}
+
+	n.Has5G = strings.Contains(specs["Technology"], "5G")
+	return n
+}
+
+// batteryKeys 可能包含电池容量的规格参数名（按优先级）
+// Display 与 Battery 都有 "Type" 字段，Specs 中以页面上靠后的 Battery 为准
+var batteryKeys = []string{"Type", "Capacity", "Battery"}
+
+// batteryMAh 解析电池容量：优先取 Battery 分组的字段（按页面顺序），
+// 其次取 batteryKeys，最后按名称顺序在其余字段中查找，保证多个值含 mAh 时结果稳定
+func batteryMAh(phone Phone) int {
+	parse := func(value string) (int, bool) {
+		m := mAhRe.FindStringSubmatch(value)
+		if m == nil {
+			return 0, false
+		}
+		v, err := strconv.Atoi(m[1])
+		return v, err == nil
+	}
+
+	for _, section := range phone.Sections {
+		if section.Name != "Battery" {
+			continue
+		}
+		for _, f := range section.Fields {
+			if v, ok := parse(f.Value); ok {
+				return v
+			}
+		}
+	}
+	for _, key := range batteryKeys {
+		if v, ok := parse(phone.Specs[key]); ok {
+			return v
+		}
+	}
+
+	keys := make([]string, 0, len(phone.Specs))
+	for key := range phone.Specs {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		if v, ok := parse(phone.Specs[key]); ok {
+			return v
+		}
+	}
+	return 0
+}
+
+// toGB 将容量换算为 GB
+func toGB(value, unit string) float64 {
+	v, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return 0
+	}
+	switch unit {
+	case "TB":
+		return v * 1024
+	case "MB":
+		return v / 1024
+	default:
+		return v
+	}
+}
@@ -0,0 +1,87 @@
+package crawler
+
+import (
+	"testing"
+)
+
+func TestNormalize(t *testing.T) {
+	for _, tc := range []struct {
+		name  string
+		phone Phone
+		want  NormalizedSpecs
+	}{
+		{"完整规格", Phone{Specs: map[string]string{
+			"Announced":  "2023, September 12",
+			"Size":       "6.7 inches, 110.2 cm2 (~88.3% screen-to-body ratio)",
+			"Resolution": "1290 x 2796 pixels, 19.5:9 ratio (~460 ppi density)",
+			"Type":       "Li-Ion 4422 mAh, non-removable (17.32 Wh)",
+			"Weight":     "221 g (7.80 oz)",
+			"Internal":   "256GB 8GB RAM, 512GB 8GB RAM, 1TB 8GB RAM",
+			"Price":      "$ 799.99 / € 1,199.00 / £ 999.00",
+			"Technology": "GSM / CDMA / HSPA / EVDO / LTE / 5G",
+		}}, NormalizedSpecs{
+			ReleaseYear: 2023, DisplaySizeInches: 6.7, DisplayWidthPx: 1290, DisplayHeightPx: 2796,
+			BatteryMAh: 4422, WeightGrams: 221, RAMGB: 8, StorageGB: 1024, PriceEUR: 1199, Has5G: true,
+		}},
+		{"旧设备", Phone{ReleaseDate: "Released 2004, March", Specs: map[string]string{
+			"Internal":   "32MB 16MB RAM",
+			"Price":      "About 120 EUR",
+			"Technology": "GSM",
+		}}, NormalizedSpecs{ReleaseYear: 2004, RAMGB: 16.0 / 1024, StorageGB: 32.0 / 1024, PriceEUR: 120}},
+		{"无法解析", Phone{Specs: map[string]string{
+			"Announced": "Not announced yet",
+			"Size":      "unknown",
+			"Weight":    "-",
+		}}, NormalizedSpecs{}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := Normalize(tc.phone); got != tc.want {
+				t.Errorf("Normalize = %+v\n期望 %+v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestNormalizeBattery(t *testing.T) {
+	for _, tc := range []struct {
+		name  string
+		phone Phone
+		want  int
+	}{
+		// Specs["Type"] 被 Display 分组覆盖时从 Battery 分组读取
+		{"Battery 分组", Phone{
+			Specs: map[string]string{"Type": "AMOLED", "Charging": "Reverse charging up to 4500 mAh"},
+			Sections: []SpecSection{
+				{Name: "Battery", Fields: []SpecField{{Name: "Type", Value: "Li-Po 5000 mAh"}, {Name: "Charging", Value: "Reverse charging up to 4500 mAh"}}},
+				{Name: "Display", Fields: []SpecField{{Name: "Type", Value: "AMOLED"}}},
+			},
+		}, 5000},
+		{"已知字段优先", Phone{Specs: map[string]string{
+			"Charging": "Power bank 10000 mAh included",
+			"Capacity": "3000 mAh",
+		}}, 3000},
+		// 多个值都含 mAh 时按字段名顺序取第一个
+		{"其余字段按名称排序", Phone{Specs: map[string]string{
+			"Stand-by":  "Up to 2000 mAh equivalent",
+			"Charging":  "Power bank 10000 mAh included",
+			"Talk time": "Up to 1500 mAh",
+		}}, 10000},
+		{"没有容量", Phone{Specs: map[string]string{"Type": "Li-Ion, removable"}}, 0},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			// map 遍历顺序随机，多次运行结果应一致
+			for range 50 {
+				if got := Normalize(tc.phone).BatteryMAh; got != tc.want {
+					t.Fatalf("BatteryMAh = %d，期望 %d", got, tc.want)
+				}
+			}
+		})
+	}
+}
+
+func TestNormalizeParsedPage(t *testing.T) {
+	n := Normalize(parseFixture(t, "phone.html", "https://www.gsmarena.com/samsung_galaxy_s24_ultra-12771.php"))
+	if n.ReleaseYear != 2024 || n.BatteryMAh != 5000 || !n.Has5G {
+		t.Errorf("Normalize = %+v，期望 2024 年、5000 mAh、支持 5G", n)
+	}
+}
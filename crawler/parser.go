@@ -0,0 +1,167 @@
+package crawler
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/gocolly/colly/v2"
+)
+
+// parseBrand 解析品牌列表页中的单个品牌链接（.st-text a）
+func parseBrand(e *colly.HTMLElement) Brand {
+	brandURL := e.Request.AbsoluteURL(e.Attr("href"))
+	brandName := strings.TrimSpace(e.DOM.Contents().First().Text())
+	devicesText := strings.TrimSpace(e.DOM.Find("span").Text())
+
+	devicesCount := 0
+	fmt.Sscanf(devicesText, "%d", &devicesCount)
+
+	return Brand{
+		Name:         brandName,
+		URL:          brandURL,
+		DevicesCount: devicesCount,
+	}
+}
+
+// parsePhoneLinks 解析列表页（品牌页 / 搜索结果页）中的手机详情页链接（.makers）
+func parsePhoneLinks(e *colly.HTMLElement) []string {
+	links := make([]string, 0)
+	e.ForEach("li a", func(_ int, el *colly.HTMLElement) {
+		links = append(links, el.Request.AbsoluteURL(el.Attr("href")))
+	})
+	return links
+}
+
+// parsePhone 解析手机详情页（#specs-list）
+func parsePhone(e *colly.HTMLElement) Phone {
+	phoneURL := e.Request.URL.String()
+
+	// 提取手机名称
+	modelName := e.DOM.ParentsUntil("body").Find(".specs-phone-name-title").Text()
+	modelName = strings.TrimSpace(modelName)
+
+	// 提取规格参数（每个 table 为一个分组，分组名在 th 中）
+	specs := make(map[string]string)
+	var sections []SpecSection
+	e.ForEach("table", func(_ int, table *colly.HTMLElement) {
+		section := SpecSection{Name: strings.TrimSpace(table.ChildText("th"))}
+		table.ForEach("tr", func(_ int, row *colly.HTMLElement) {
+			key := strings.TrimSpace(row.ChildText(".ttl"))
+			value := specValue(row.DOM.Find(".nfo"))
+			if key != "" {
+				specs[key] = value
+				section.Fields = append(section.Fields, SpecField{Name: key, Value: value})
+			}
+		})
+		if len(section.Fields) > 0 {
+			sections = append(sections, section)
+		}
+	})
+
+	// 提取发布日期
+	releaseDate := specs["Released"]
+	if releaseDate == "" {
+		releaseDate = "Unknown"
+	}
+
+	return Phone{
+		SchemaVersion: SchemaVersion,
+		ModelName:     modelName,
+		Brand:         extractBrandFromURL(phoneURL),
+		ReleaseDate:   releaseDate,
+		URL:           phoneURL,
+		Specs:         specs,
+		CrawledAt:     time.Now().Format(time.RFC3339),
+		Sections:      sections,
+	}
+}
+
+// specValue 读取规格参数值，以 <br> 分隔的多行值用换行连接
+// ChildText 会把各行直接拼接在一起；源码中的换行和连续空白按页面显示合并为一个空格
+func specValue(cell *goquery.Selection) string {
+	var lines []string
+	var line strings.Builder
+	flush := func() {
+		if v := strings.Join(strings.Fields(line.String()), " "); v != "" {
+			lines = append(lines, v)
+		}
+		line.Reset()
+	}
+	var walk func(s *goquery.Selection)
+	walk = func(s *goquery.Selection) {
+		s.Contents().Each(func(_ int, node *goquery.Selection) {
+			switch goquery.NodeName(node) {
+			case "#text":
+				line.WriteString(node.Text())
+			case "br":
+				flush()
+			default:
+				walk(node)
+			}
+		})
+	}
+	walk(cell)
+	flush()
+	return strings.Join(lines, "\n")
+}
+
+// extractBrandInfo 从品牌URL中提取品牌标识和ID
+// 输入: https://www.gsmarena.com/doogee-phones-129.php
+// 输出: ("doogee", "129")
+func extractBrandInfo(url string) (brandSlug string, brandID string) {
+	parts := strings.Split(url, "/")
+	if len(parts) == 0 {
+		return "", ""
+	}
+
+	lastPart := parts[len(parts)-1]
+	lastPart = strings.TrimSuffix(lastPart, ".php")
+
+	// 格式: brand-phones-id
+	segments := strings.Split(lastPart, "-phones-")
+	if len(segments) != 2 {
+		return "", ""
+	}
+
+	brandSlug = segments[0]
+	brandID = segments[1]
+
+	return brandSlug, brandID
+}
+
+// DeviceID 从详情页 URL 中提取设备 ID，无法解析时返回空字符串
+// 例如: https://www.gsmarena.com/apple_iphone_15_pro_max-12548.php -> "12548"
+func DeviceID(url string) string {
+	name := url[strings.LastIndex(url, "/")+1:]
+	name = strings.TrimSuffix(name, ".php")
+
+	i := strings.LastIndex(name, "-")
+	if i < 0 || i == len(name)-1 {
+		return ""
+	}
+	id := name[i+1:]
+	for _, r := range id {
+		if r < '0' || r > '9' {
+			return ""
+		}
+	}
+	return id
+}
+
+// extractBrandFromURL 从 URL 中提取品牌名称
+// 例如: https://www.gsmarena.com/apple-phones-48.php -> "Apple"
+func extractBrandFromURL(url string) string {
+	parts := strings.Split(url, "/")
+	if len(parts) > 0 {
+		lastPart := parts[len(parts)-1]
+		// 移除 "-phones-xx.php" 后缀
+		brandPart := strings.Split(lastPart, "-phones-")
+		if len(brandPart) > 0 {
+			brand := strings.ReplaceAll(brandPart[0], "-", " ")
+			return strings.Title(strings.ToLower(brand))
+		}
+	}
+	return "Unknown"
+}
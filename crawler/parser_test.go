@@ -0,0 +1,62 @@
+package crawler
+
+import (
+	"net/url"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/gocolly/colly/v2"
+)
+
+// parseFixture 按详情页回调的方式解析 testdata 中的页面
+func parseFixture(t *testing.T, name, pageURL string) Phone {
+	t.Helper()
+	f, err := os.Open("testdata/" + name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	doc, err := goquery.NewDocumentFromReader(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	u, err := url.Parse(pageURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	list := doc.Find("#specs-list")
+	resp := &colly.Response{Request: &colly.Request{URL: u}}
+	return parsePhone(colly.NewHTMLElementFromSelectionNode(resp, list, list.Nodes[0], 0))
+}
+
+func TestParsePhone(t *testing.T) {
+	phone := parseFixture(t, "phone.html", "https://www.gsmarena.com/samsung_galaxy_s24_ultra-12771.php")
+
+	if phone.ModelName != "Samsung Galaxy S24 Ultra" {
+		t.Errorf("ModelName = %q", phone.ModelName)
+	}
+	if len(phone.Sections) != 4 || phone.Sections[2].Name != "Main Camera" {
+		t.Fatalf("Sections = %+v", phone.Sections)
+	}
+
+	for key, want := range map[string]string{
+		"Technology": "GSM / CDMA / HSPA / EVDO / LTE / 5G",
+		"Status":     "Available. Released 2024, January 24",
+		// <br> 分隔的多行值以换行连接，<br/> 后是否换行都一样
+		"Quad": strings.Join([]string{
+			"200 MP, f/1.7, 24mm (wide), multi-directional PDAF, OIS",
+			"50 MP, f/3.4, 111mm (periscope telephoto), PDAF, OIS, 5x optical zoom",
+			"10 MP, f/2.4, 67mm (telephoto), PDAF, OIS, 3x optical zoom",
+			"12 MP, f/2.2, 13mm, 120˚ (ultrawide), Super Steady video",
+		}, "\n"),
+		// 源码中的换行不是多行值
+		"Features": "LED flash, auto-HDR, panorama",
+		"Charging": "45W wired, PD3.0, 65% in 30 min (advertised)\n15W wireless (Qi/PMA)\n4.5W reverse wireless",
+	} {
+		if got := phone.Specs[key]; got != want {
+			t.Errorf("Specs[%q] = %q，期望 %q", key, got, want)
+		}
+	}
+}
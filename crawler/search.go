@@ -0,0 +1,212 @@
+package crawler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gocolly/colly/v2"
+)
+
+// 搜索结果页地址（快速搜索与 Phone Finder 共用同一个结果页）
+const SearchResultsURL = "https://www.gsmarena.com/results.php3"
+
+// finderFlags Phone Finder 中的开关类过滤条件（关键字 -> 表单参数）
+var finderFlags = map[string]string{
+	"5g":   "chk5G",
+	"nfc":  "chkNFC",
+	"esim": "chkESIM",
+	"jack": "chk35mmJack",
+}
+
+// finderRanges 带单位的数值过滤条件（单位 -> 最小值/最大值参数）
+var finderRanges = map[string][2]string{
+	"mah":  {"nBatCapacityMin", "nBatCapacityMax"},
+	"inch": {"fDisplayInchesMin", "fDisplayInchesMax"},
+	"in":   {"fDisplayInchesMin", "fDisplayInchesMax"},
+	"\"":   {"fDisplayInchesMin", "fDisplayInchesMax"},
+	"mp":   {"nCamera1Min", "nCamera1Max"},
+	"gb":   {"nRamMin", "nRamMax"},
+	"eur":  {"nPriceMin", "nPriceMax"},
+	"year": {"nYearMin", "nYearMax"},
+}
+
+// minFinderYear Phone Finder 年份条件的下限（GSMArena 收录的最早机型）
+const minFinderYear = 1994
+
+// finderRangeRe 匹配数值条件，如 ">=5000 mAh"、"<6.5 inch"、"2024"
+var finderRangeRe = regexp.MustCompile(`^(>=|<=|>|<|=)?\s*(\d+(?:\.\d+)?)\s*([a-z"]*)$`)
+
+// parseFinderFilters 将逗号分隔的过滤表达式解析为 Phone Finder 查询参数
+// 输入: "5G, 2024, >=5000 mAh"
+// 输出: chk5G=selected&nYearMin=2024&nYearMax=2024&nBatCapacityMin=5000
+// 支持的写法:
+//   - 开关: 5G / NFC / eSIM / jack
+//   - 数值: [>=|<=|>|<|=]数字[单位]，单位为 mAh/inch/MP/GB/EUR，不带单位的四位数视为年份
+//     （年份需在 1994 到明年之间，"5000" 这类漏写单位的数值返回错误）
+//   - 原始参数: name=value（直接透传到结果页，用于未内置的过滤条件）
+//   - 其他文本: 作为自由文本搜索 (sFreeText)
+func parseFinderFilters(expr string) (url.Values, error) {
+	params := url.Values{}
+	freeText := make([]string, 0)
+
+	for _, token := range strings.Split(expr, ",") {
+		token = strings.TrimSpace(token)
+		if token == "" {
+			continue
+		}
+		lower := strings.ToLower(token)
+
+		// 开关类条件
+		if param, ok := finderFlags[lower]; ok {
+			params.Set(param, "selected")
+			continue
+		}
+
+		// 原始参数透传（排除 ">=" 等比较符）
+		if name, value, ok := strings.Cut(token, "="); ok && !strings.ContainsAny(name, "<>") && name != "" {
+			params.Set(strings.TrimSpace(name), strings.TrimSpace(value))
+			continue
+		}
+
+		// 数值范围条件
+		if m := finderRangeRe.FindStringSubmatch(lower); m != nil {
+			op, number, unit := m[1], m[2], m[3]
+			if unit == "" {
+				if len(number) != 4 {
+					return nil, fmt.Errorf("无法识别的过滤条件（缺少单位）: %q", token)
+				}
+				unit = "year"
+			}
+			if unit == "year" {
+				if err := checkFinderYear(number); err != nil {
+					return nil, fmt.Errorf("%w: %q", err, token)
+				}
+			}
+			names, ok := finderRanges[unit]
+			if !ok {
+				return nil, fmt.Errorf("不支持的单位 %q: %q", unit, token)
+			}
+			switch op {
+			case ">=", ">":
+				params.Set(names[0], number)
+			case "<=", "<":
+				params.Set(names[1], number)
+			default:
+				params.Set(names[0], number)
+				params.Set(names[1], number)
+			}
+			continue
+		}
+
+		freeText = append(freeText, token)
+	}
+
+	if len(freeText) > 0 {
+		params.Set("sFreeText", strings.Join(freeText, " "))
+	}
+	if len(params) == 0 {
+		return nil, fmt.Errorf("过滤表达式为空: %q", expr)
+	}
+	return params, nil
+}
+
+// checkFinderYear 检查年份是否合理（minFinderYear 到明年）
+func checkFinderYear(number string) error {
+	year, err := strconv.Atoi(number)
+	if err != nil || year < minFinderYear || year > time.Now().Year()+1 {
+		return fmt.Errorf("年份超出范围 %d-%d（数值条件是否缺少单位？）", minFinderYear, time.Now().Year()+1)
+	}
+	return nil
+}
+
+// BuildSearchURL 根据关键字和过滤表达式构造搜索结果页 URL
+// 只有 keyword 时使用快速搜索；同时有过滤条件时 keyword 作为 Phone Finder 的型号名称条件 (sName)，
+// 与其他过滤条件一起生效（快速搜索会忽略过滤条件）
+func BuildSearchURL(keyword, filters string) (string, error) {
+	params := url.Values{}
+
+	if filters != "" {
+		finderParams, err := parseFinderFilters(filters)
+		if err != nil {
+			return "", err
+		}
+		params = finderParams
+	}
+
+	if keyword != "" {
+		if len(params) == 0 {
+			params.Set("sQuickSearch", "yes")
+		}
+		params.Set("sName", keyword)
+	}
+
+	if len(params) == 0 {
+		return "", fmt.Errorf("关键字和过滤条件不能同时为空")
+	}
+	return SearchResultsURL + "?" + params.Encode(), nil
+}
+
+// FetchSearchLinks 发现模式: 通过搜索结果页获取手机详情页链接
+// 代替阶段 1/2 的全量品牌遍历，结果直接交给阶段 3
+func (c *Crawler) FetchSearchLinks(ctx context.Context, searchURL string) ([]string, error) {
+	phoneLinkSet := make(map[string]bool)
+	var linksMutex sync.Mutex
+
+	ctx, stop := context.WithCancelCause(ctx)
+	defer stop(nil)
+	collector, abort, err := c.newCollector(ctx, stop)
+	if err != nil {
+		return nil, fmt.Errorf("创建 collector 失败: %w", err)
+	}
+	defer abort()
+
+	// 解析结果列表（与品牌列表页结构相同）
+	collector.OnHTML(".makers", func(e *colly.HTMLElement) {
+		linkCount := 0
+		for _, phoneURL := range parsePhoneLinks(e) {
+			linksMutex.Lock()
+			if !phoneLinkSet[phoneURL] {
+				phoneLinkSet[phoneURL] = true
+				linkCount++
+				log.Printf("[发现] 搜索结果 #%d: %s", len(phoneLinkSet), phoneURL)
+			}
+			linksMutex.Unlock()
+		}
+		log.Printf("[本页统计] 本页发现 %d 个新设备: %s", linkCount, e.Request.URL)
+	})
+
+	// 结果较多时会分页，跟随分页链接
+	collector.OnHTML(".nav-pages a[href]", func(e *colly.HTMLElement) {
+		pageURL := e.Request.AbsoluteURL(e.Attr("href"))
+		if !strings.Contains(pageURL, "results.php3") || ctx.Err() != nil {
+			return
+		}
+		var visitedErr *colly.AlreadyVisitedError
+		if err := e.Request.Visit(pageURL); err != nil && !errors.As(err, &visitedErr) {
+			log.Printf("  [错误] 访问分页失败: %v", err)
+		}
+	})
+
+	log.Printf("[搜索] %s", searchURL)
+	if err := collector.Visit(searchURL); err != nil {
+		return nil, fmt.Errorf("访问搜索结果页失败: %w", err)
+	}
+	c.wait(ctx, collector, abort)
+
+	phoneLinks := make([]string, 0, len(phoneLinkSet))
+	for link := range phoneLinkSet {
+		phoneLinks = append(phoneLinks, link)
+	}
+	if ctx.Err() != nil {
+		return phoneLinks, fmt.Errorf("搜索被中断: %w", context.Cause(ctx))
+	}
+	return phoneLinks, nil
+}
@@ -0,0 +1,91 @@
+package crawler
+
+import (
+	"net/url"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestParseFinderFilters(t *testing.T) {
+	nextYear := strconv.Itoa(time.Now().Year() + 1)
+	for _, tc := range []struct {
+		expr string
+		want string // 编码后的查询参数，为空表示期望返回错误
+	}{
+		{"5G, 2024, >=5000 mAh", "chk5G=selected&nBatCapacityMin=5000&nYearMax=2024&nYearMin=2024"},
+		{"NFC, eSIM, jack", "chk35mmJack=selected&chkESIM=selected&chkNFC=selected"},
+		{"<6.5 inch, > 50 MP, <=8GB, <= 500 EUR", "fDisplayInchesMax=6.5&nCamera1Min=50&nPriceMax=500&nRamMax=8"},
+		{`=6.1", >=6 in`, "fDisplayInchesMax=6.1&fDisplayInchesMin=6"},
+		{">=2020, <2023", "nYearMax=2023&nYearMin=2020"},
+		{">=2020 year", "nYearMin=2020"},
+		{"1994", "nYearMax=1994&nYearMin=1994"},
+		{nextYear, "nYearMax=" + nextYear + "&nYearMin=" + nextYear},
+		{"sOSes=2, idMaker = 9", "idMaker=9&sOSes=2"},
+		{"foldable, dual sim", "sFreeText=foldable+dual+sim"},
+		{" , 5g ,, ", "chk5G=selected"},
+		// 漏写单位或超出范围的年份
+		{"5000", ""},
+		{">=5000", ""},
+		{"1993", ""},
+		{"2024.5", ""},
+		{"3000 year", ""},
+		{">=5000 mah, 9999", ""},
+		// 缺少单位的非四位数
+		{"128", ""},
+		{"50 kg", ""},
+		{"", ""},
+		{" , ", ""},
+	} {
+		t.Run(tc.expr, func(t *testing.T) {
+			params, err := parseFinderFilters(tc.expr)
+			if tc.want == "" {
+				if err == nil {
+					t.Fatalf("期望返回错误，得到 %s", params.Encode())
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := params.Encode(); got != tc.want {
+				t.Errorf("参数 = %s，期望 %s", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestBuildSearchURL(t *testing.T) {
+	for _, tc := range []struct {
+		name            string
+		keyword, filter string
+		want            string // 查询参数，为空表示期望返回错误
+	}{
+		{"快速搜索", "galaxy s24", "", "sName=galaxy+s24&sQuickSearch=yes"},
+		// 有过滤条件时关键字作为 Phone Finder 的型号名称条件
+		{"关键字加过滤条件", "galaxy", "5G, 2024", "chk5G=selected&nYearMax=2024&nYearMin=2024&sName=galaxy"},
+		{"只有过滤条件", "", "nfc", "chkNFC=selected"},
+		{"都为空", "", "", ""},
+		{"过滤条件无效", "galaxy", "5000", ""},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := BuildSearchURL(tc.keyword, tc.filter)
+			if tc.want == "" {
+				if err == nil {
+					t.Fatalf("期望返回错误，得到 %s", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			u, err := url.Parse(got)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if base := u.Scheme + "://" + u.Host + u.Path; base != SearchResultsURL || u.RawQuery != tc.want {
+				t.Errorf("URL = %s，期望 %s?%s", got, SearchResultsURL, tc.want)
+			}
+		})
+	}
+}
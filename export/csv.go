@@ -0,0 +1,286 @@
+package export
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/yangbin1322/go-gsmarena/crawler"
+)
+
+// MultiValueMode 多值字段（规格参数中以换行分隔的多行值）的展开方式
+type MultiValueMode string
+
+const (
+	// MultiJoin 用分隔符合并为一个单元格
+	MultiJoin MultiValueMode = "join"
+	// MultiFirst 只保留第一个值
+	MultiFirst MultiValueMode = "first"
+	// MultiSplit 展开为多列: Key[1]、Key[2]...（列数取所有设备中的最大值）
+	MultiSplit MultiValueMode = "split"
+)
+
+// topLevelFields Phone 顶层字段（列名 -> 取值）
+var topLevelFields = []struct {
+	Name  string
+	Value func(p crawler.Phone) string
+}{
+	{"model_name", func(p crawler.Phone) string { return p.ModelName }},
+	{"brand", func(p crawler.Phone) string { return p.Brand }},
+	{"release_date", func(p crawler.Phone) string { return p.ReleaseDate }},
+	{"url", func(p crawler.Phone) string { return p.URL }},
+	{"crawled_at", func(p crawler.Phone) string { return p.CrawledAt }},
+}
+
+// CSVOptions CSV/TSV 导出配置
+type CSVOptions struct {
+	// Comma 字段分隔符，',' 为 CSV，'\t' 为 TSV
+	Comma rune
+
+	// Columns 列选择及顺序，每项格式为 "字段" 或 "表头=字段"
+	// 字段为顶层字段名（model_name 等）或规格参数名，"specs.名称" 强制按规格参数查找；
+	// 规格参数名为 "分组.字段"（如 "Display.Type"），也可以只写字段名（取 Specs 中的值，不同分组的同名字段以最后一个为准）
+	// 为空时导出全部顶层字段及所有设备规格参数的并集（按名称排序）
+	Columns []string
+
+	// Multi 多值字段展开方式，默认 MultiJoin
+	Multi MultiValueMode
+
+	// Separator MultiJoin 模式下的分隔符，默认 "; "
+	Separator string
+}
+
+// CSVStats 导出统计
+type CSVStats struct {
+	Rows    int // 导出的行数
+	Columns int // 导出的列数
+}
+
+// column 解析后的导出列
+type column struct {
+	header string
+	top    func(p crawler.Phone) string // 顶层字段取值（规格参数列为 nil）
+	spec   string                       // 规格参数名
+	index  int                          // MultiSplit 模式下取第几个值（从 0 开始），-1 表示按 Multi 规则处理
+}
+
+// WriteCSV 将数据源导出为 CSV/TSV
+// 需要统计字段并集或多值列数时会遍历数据源两次
+func WriteCSV(w io.Writer, src Source, opts CSVOptions) (*CSVStats, error) {
+	if opts.Comma == 0 {
+		opts.Comma = ','
+	}
+	if opts.Multi == "" {
+		opts.Multi = MultiJoin
+	}
+	if opts.Separator == "" {
+		opts.Separator = "; "
+	}
+	switch opts.Multi {
+	case MultiJoin, MultiFirst, MultiSplit:
+	default:
+		return nil, fmt.Errorf("不支持的多值展开方式: %q", opts.Multi)
+	}
+
+	columns, err := resolveColumns(src, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	writer := csv.NewWriter(w)
+	writer.Comma = opts.Comma
+
+	header := make([]string, len(columns))
+	for i, col := range columns {
+		header[i] = col.header
+	}
+	if err := writer.Write(header); err != nil {
+		return nil, fmt.Errorf("写入表头失败: %w", err)
+	}
+
+	stats := &CSVStats{Columns: len(columns)}
+	row := make([]string, len(columns))
+	err = src.Each(func(phone crawler.Phone) error {
+		fields := specFields(phone)
+		for i, col := range columns {
+			row[i] = col.value(phone, fields, opts)
+		}
+		stats.Rows++
+		return writer.Write(row)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		return nil, fmt.Errorf("写入 CSV 失败: %w", err)
+	}
+	return stats, nil
+}
+
+// resolveColumns 根据配置生成导出列
+func resolveColumns(src Source, opts CSVOptions) ([]column, error) {
+	// 第一遍：统计规格参数名的并集及每个参数的最大值个数
+	var specKeys []string
+	maxValues := make(map[string]int)
+	if len(opts.Columns) == 0 || opts.Multi == MultiSplit {
+		keys := make(map[string]bool)
+		count := func(key, value string) {
+			n := len(splitValues(value))
+			if current, ok := maxValues[key]; !ok || n > current {
+				maxValues[key] = n
+			}
+		}
+		err := src.Each(func(phone crawler.Phone) error {
+			fields := specFields(phone)
+			for key, value := range fields {
+				keys[key] = true
+				count(key, value)
+			}
+			// 只写字段名的列取 Specs 中的值，同样需要统计值个数
+			for key, value := range phone.Specs {
+				if _, ok := fields[key]; !ok {
+					count(key, value)
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+		for key := range keys {
+			specKeys = append(specKeys, key)
+		}
+		sort.Strings(specKeys)
+	}
+
+	// 未指定列时使用全部字段
+	if len(opts.Columns) == 0 {
+		columns := make([]column, 0, len(topLevelFields)+len(specKeys))
+		for _, f := range topLevelFields {
+			columns = append(columns, column{header: f.Name, top: f.Value, index: -1})
+		}
+		specs := make([]column, 0, len(specKeys))
+		for _, key := range specKeys {
+			specs = append(specs, column{header: key, spec: key, index: -1})
+		}
+		return append(columns, expandSplit(specs, maxValues, opts)...), nil
+	}
+
+	columns := make([]column, 0, len(opts.Columns))
+	for _, item := range opts.Columns {
+		header, field, ok := strings.Cut(item, "=")
+		if !ok {
+			field = header
+		}
+		header, field = strings.TrimSpace(header), strings.TrimSpace(field)
+		if field == "" {
+			return nil, fmt.Errorf("列配置为空: %q", item)
+		}
+		if header == "" || !ok {
+			header = strings.TrimPrefix(field, "specs.")
+		}
+
+		if key, ok := strings.CutPrefix(field, "specs."); ok {
+			columns = append(columns, expandSplit([]column{{header: header, spec: key, index: -1}}, maxValues, opts)...)
+			continue
+		}
+		if top := lookupTopLevel(field); top != nil {
+			columns = append(columns, column{header: header, top: top, index: -1})
+			continue
+		}
+		columns = append(columns, expandSplit([]column{{header: header, spec: field, index: -1}}, maxValues, opts)...)
+	}
+	return columns, nil
+}
+
+// expandSplit MultiSplit 模式下将规格参数列展开为 Key[1]、Key[2]...
+func expandSplit(specs []column, maxValues map[string]int, opts CSVOptions) []column {
+	if opts.Multi != MultiSplit {
+		return specs
+	}
+	expanded := make([]column, 0, len(specs))
+	for _, col := range specs {
+		n := maxValues[col.spec]
+		if n <= 1 {
+			col.index = 0
+			expanded = append(expanded, col)
+			continue
+		}
+		for i := 0; i < n; i++ {
+			expanded = append(expanded, column{
+				header: fmt.Sprintf("%s[%d]", col.header, i+1),
+				spec:   col.spec,
+				index:  i,
+			})
+		}
+	}
+	return expanded
+}
+
+// lookupTopLevel 查找顶层字段取值函数
+func lookupTopLevel(name string) func(p crawler.Phone) string {
+	for _, f := range topLevelFields {
+		if f.Name == name {
+			return f.Value
+		}
+	}
+	return nil
+}
+
+// specFields 返回记录的规格参数（列名 -> 值）
+// 按 Sections 生成 "分组.字段" 列（如 "Display.Type"、"Battery.Type"），避免不同分组的同名字段互相覆盖；
+// 没有 Sections 的旧记录使用 Specs（列名为字段名）
+func specFields(phone crawler.Phone) map[string]string {
+	if len(phone.Sections) == 0 {
+		return phone.Specs
+	}
+	fields := make(map[string]string)
+	for _, section := range phone.Sections {
+		for _, f := range section.Fields {
+			fields[section.Name+"."+f.Name] = f.Value
+		}
+	}
+	return fields
+}
+
+// value 计算单元格的值，fields 为 specFields 的结果
+func (col column) value(phone crawler.Phone, fields map[string]string, opts CSVOptions) string {
+	if col.top != nil {
+		return col.top(phone)
+	}
+
+	value, ok := fields[col.spec]
+	if !ok {
+		value = phone.Specs[col.spec]
+	}
+	values := splitValues(value)
+	if len(values) == 0 {
+		return ""
+	}
+	switch {
+	case col.index >= 0:
+		if col.index < len(values) {
+			return values[col.index]
+		}
+		return ""
+	case opts.Multi == MultiFirst:
+		return values[0]
+	default:
+		return strings.Join(values, opts.Separator)
+	}
+}
+
+// splitValues 拆分多值字段（详情页中以 <br> 分隔的多行值）
+func splitValues(value string) []string {
+	values := make([]string, 0, 1)
+	for _, v := range strings.Split(value, "\n") {
+		if v = strings.TrimSpace(v); v != "" {
+			values = append(values, v)
+		}
+	}
+	return values
+}
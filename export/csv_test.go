@@ -0,0 +1,135 @@
+package export
+
+import (
+	"bytes"
+	"encoding/csv"
+	"reflect"
+	"testing"
+
+	"github.com/yangbin1322/go-gsmarena/crawler"
+)
+
+// phones 内存中的数据源
+type phones []crawler.Phone
+
+func (s phones) Each(fn func(phone crawler.Phone) error) error {
+	for _, phone := range s {
+		if err := fn(phone); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// csvPhones 两台设备：相机规格分别有 3 行和 2 行，只有第二台有 NFC
+var csvPhones = phones{
+	{
+		ModelName: "Phone A",
+		Brand:     "Acme",
+		URL:       "https://www.gsmarena.com/acme_phone_a-1.php",
+		Specs:     map[string]string{"Quad": "200 MP, f/1.7\n50 MP, f/3.4\n12 MP, f/2.2", "Type": "Li-Ion 5000 mAh"},
+		Sections: []crawler.SpecSection{
+			{Name: "Main Camera", Fields: []crawler.SpecField{{Name: "Quad", Value: "200 MP, f/1.7\n50 MP, f/3.4\n12 MP, f/2.2"}}},
+			{Name: "Battery", Fields: []crawler.SpecField{{Name: "Type", Value: "Li-Ion 5000 mAh"}}},
+		},
+	},
+	{
+		ModelName: "Phone B",
+		Brand:     "Acme",
+		URL:       "https://www.gsmarena.com/acme_phone_b-2.php",
+		Specs:     map[string]string{"Quad": "48 MP\n8 MP", "NFC": "Yes"},
+		Sections: []crawler.SpecSection{
+			{Name: "Main Camera", Fields: []crawler.SpecField{{Name: "Quad", Value: "48 MP\n8 MP"}}},
+			{Name: "Comms", Fields: []crawler.SpecField{{Name: "NFC", Value: "Yes"}}},
+		},
+	},
+}
+
+// writeCSV 导出并解析结果
+func writeCSV(t *testing.T, src Source, opts CSVOptions) [][]string {
+	t.Helper()
+	var buf bytes.Buffer
+	stats, err := WriteCSV(&buf, src, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := csv.NewReader(&buf)
+	r.Comma = opts.Comma
+	if r.Comma == 0 {
+		r.Comma = ','
+	}
+	rows, err := r.ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats.Rows != len(rows)-1 || stats.Columns != len(rows[0]) {
+		t.Errorf("统计 = %+v，实际 %d 行 %d 列", stats, len(rows)-1, len(rows[0]))
+	}
+	return rows
+}
+
+func TestWriteCSVMultiModes(t *testing.T) {
+	columns := []string{"model_name", "相机=Main Camera.Quad", "Comms.NFC"}
+	for _, tc := range []struct {
+		name string
+		opts CSVOptions
+		want [][]string
+	}{
+		{"合并", CSVOptions{Columns: columns}, [][]string{
+			{"model_name", "相机", "Comms.NFC"},
+			{"Phone A", "200 MP, f/1.7; 50 MP, f/3.4; 12 MP, f/2.2", ""},
+			{"Phone B", "48 MP; 8 MP", "Yes"},
+		}},
+		{"自定义分隔符", CSVOptions{Columns: columns, Separator: " | ", Comma: '\t'}, [][]string{
+			{"model_name", "相机", "Comms.NFC"},
+			{"Phone A", "200 MP, f/1.7 | 50 MP, f/3.4 | 12 MP, f/2.2", ""},
+			{"Phone B", "48 MP | 8 MP", "Yes"},
+		}},
+		{"只取第一个值", CSVOptions{Columns: columns, Multi: MultiFirst}, [][]string{
+			{"model_name", "相机", "Comms.NFC"},
+			{"Phone A", "200 MP, f/1.7", ""},
+			{"Phone B", "48 MP", "Yes"},
+		}},
+		// 列数取所有设备中的最大值，单值字段不加下标
+		{"展开为多列", CSVOptions{Columns: columns, Multi: MultiSplit}, [][]string{
+			{"model_name", "相机[1]", "相机[2]", "相机[3]", "Comms.NFC"},
+			{"Phone A", "200 MP, f/1.7", "50 MP, f/3.4", "12 MP, f/2.2", ""},
+			{"Phone B", "48 MP", "8 MP", "", "Yes"},
+		}},
+		// 只写字段名时取 Specs 中的值
+		{"字段名列展开", CSVOptions{Columns: []string{"specs.Quad"}, Multi: MultiSplit}, [][]string{
+			{"Quad[1]", "Quad[2]", "Quad[3]"},
+			{"200 MP, f/1.7", "50 MP, f/3.4", "12 MP, f/2.2"},
+			{"48 MP", "8 MP", ""},
+		}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := writeCSV(t, csvPhones, tc.opts); !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("导出结果 = %q\n期望 %q", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestWriteCSVAllColumns(t *testing.T) {
+	rows := writeCSV(t, csvPhones, CSVOptions{Multi: MultiSplit})
+	// 顶层字段在前，规格参数按名称排序
+	want := []string{"model_name", "brand", "release_date", "url", "crawled_at",
+		"Battery.Type", "Comms.NFC", "Main Camera.Quad[1]", "Main Camera.Quad[2]", "Main Camera.Quad[3]"}
+	if !reflect.DeepEqual(rows[0], want) {
+		t.Errorf("表头 = %q\n期望 %q", rows[0], want)
+	}
+	if len(rows) != 3 || rows[2][6] != "Yes" || rows[2][9] != "" {
+		t.Errorf("数据行 = %q", rows[1:])
+	}
+}
+
+func TestWriteCSVInvalidOptions(t *testing.T) {
+	var buf bytes.Buffer
+	if _, err := WriteCSV(&buf, csvPhones, CSVOptions{Multi: "explode"}); err == nil {
+		t.Error("不支持的展开方式应返回错误")
+	}
+	if _, err := WriteCSV(&buf, csvPhones, CSVOptions{Columns: []string{"表头="}}); err == nil {
+		t.Error("空列配置应返回错误")
+	}
+}
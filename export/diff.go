@@ -0,0 +1,199 @@
+package export
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/yangbin1322/go-gsmarena/crawler"
+)
+
+// 字段变更类型
+const (
+	ChangeAdded   = "added"
+	ChangeRemoved = "removed"
+	ChangeChanged = "changed"
+)
+
+// Device 差异报告中的设备
+type Device struct {
+	ID        string `json:"id"` // 去重键，见 DeviceKey
+	ModelName string `json:"model_name"`
+	Brand     string `json:"brand"`
+	URL       string `json:"url"`
+}
+
+// FieldChange 单个字段的变更，规格参数字段名带 "specs." 前缀
+type FieldChange struct {
+	Field string `json:"field"`
+	Kind  string `json:"kind"` // added / removed / changed
+	Old   string `json:"old,omitempty"`
+	New   string `json:"new,omitempty"`
+}
+
+// DeviceChanges 一台设备的字段变更
+type DeviceChanges struct {
+	Device
+	Changes []FieldChange `json:"changes"`
+}
+
+// DiffReport 两次抓取结果的差异
+type DiffReport struct {
+	Added     []Device        `json:"added"`
+	Removed   []Device        `json:"removed"`
+	Changed   []DeviceChanges `json:"changed"`
+	Unchanged int             `json:"unchanged"`
+}
+
+// diffFields 参与比较的顶层字段（crawled_at、schema_version 每次抓取都可能不同，不比较）
+var diffFields = []string{"model_name", "brand", "release_date", "url"}
+
+// Diff 比较两个数据源，同一设备出现多次时取最新的记录（与 Snapshot 相同）
+func Diff(oldSrc, newSrc Source) (*DiffReport, error) {
+	oldDevices, err := loadLatest(oldSrc)
+	if err != nil {
+		return nil, err
+	}
+	newDevices, err := loadLatest(newSrc)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &DiffReport{Added: []Device{}, Removed: []Device{}, Changed: []DeviceChanges{}}
+	for key, phone := range newDevices {
+		before, ok := oldDevices[key]
+		if !ok {
+			report.Added = append(report.Added, deviceOf(key, phone))
+			continue
+		}
+		if changes := diffPhone(before, phone); len(changes) > 0 {
+			report.Changed = append(report.Changed, DeviceChanges{Device: deviceOf(key, phone), Changes: changes})
+		} else {
+			report.Unchanged++
+		}
+	}
+	for key, phone := range oldDevices {
+		if _, ok := newDevices[key]; !ok {
+			report.Removed = append(report.Removed, deviceOf(key, phone))
+		}
+	}
+
+	sortDevices(report.Added)
+	sortDevices(report.Removed)
+	sort.Slice(report.Changed, func(i, j int) bool {
+		return lessDevice(report.Changed[i].Device, report.Changed[j].Device)
+	})
+	return report, nil
+}
+
+// loadLatest 读取数据源中每个设备最新的记录
+func loadLatest(src Source) (map[string]crawler.Phone, error) {
+	devices := make(map[string]crawler.Phone)
+	err := src.Each(func(phone crawler.Phone) error {
+		key := DeviceKey(phone)
+		if cur, ok := devices[key]; !ok || phone.CrawledAt >= cur.CrawledAt {
+			devices[key] = phone
+		}
+		return nil
+	})
+	return devices, err
+}
+
+// diffPhone 比较顶层字段和规格参数，结果按字段名排序
+func diffPhone(before, after crawler.Phone) []FieldChange {
+	var changes []FieldChange
+	for _, field := range diffFields {
+		top := lookupTopLevel(field)
+		if o, n := top(before), top(after); o != n {
+			changes = append(changes, FieldChange{Field: field, Kind: ChangeChanged, Old: o, New: n})
+		}
+	}
+
+	keys := make(map[string]bool)
+	for key := range before.Specs {
+		keys[key] = true
+	}
+	for key := range after.Specs {
+		keys[key] = true
+	}
+	names := make([]string, 0, len(keys))
+	for key := range keys {
+		names = append(names, key)
+	}
+	sort.Strings(names)
+
+	for _, key := range names {
+		o, inOld := before.Specs[key]
+		n, inNew := after.Specs[key]
+		field := "specs." + key
+		switch {
+		case !inOld:
+			changes = append(changes, FieldChange{Field: field, Kind: ChangeAdded, New: n})
+		case !inNew:
+			changes = append(changes, FieldChange{Field: field, Kind: ChangeRemoved, Old: o})
+		case o != n:
+			changes = append(changes, FieldChange{Field: field, Kind: ChangeChanged, Old: o, New: n})
+		}
+	}
+	return changes
+}
+
+func deviceOf(key string, phone crawler.Phone) Device {
+	return Device{ID: key, ModelName: phone.ModelName, Brand: phone.Brand, URL: phone.URL}
+}
+
+func sortDevices(devices []Device) {
+	sort.Slice(devices, func(i, j int) bool { return lessDevice(devices[i], devices[j]) })
+}
+
+// lessDevice 按品牌、型号名称、ID 排序
+func lessDevice(a, b Device) bool {
+	if a.Brand != b.Brand {
+		return a.Brand < b.Brand
+	}
+	if a.ModelName != b.ModelName {
+		return a.ModelName < b.ModelName
+	}
+	return a.ID < b.ID
+}
+
+// WriteText 输出便于人工审阅的差异报告
+func (r *DiffReport) WriteText(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+
+	fmt.Fprintf(bw, "新增设备 (%d):\n", len(r.Added))
+	for _, d := range r.Added {
+		fmt.Fprintf(bw, "  + %s\n", d)
+	}
+	fmt.Fprintf(bw, "\n移除设备 (%d):\n", len(r.Removed))
+	for _, d := range r.Removed {
+		fmt.Fprintf(bw, "  - %s\n", d)
+	}
+	fmt.Fprintf(bw, "\n变更设备 (%d):\n", len(r.Changed))
+	for _, d := range r.Changed {
+		fmt.Fprintf(bw, "  ~ %s\n", d.Device)
+		for _, c := range d.Changes {
+			switch c.Kind {
+			case ChangeAdded:
+				fmt.Fprintf(bw, "      + %s: %q\n", c.Field, c.New)
+			case ChangeRemoved:
+				fmt.Fprintf(bw, "      - %s: %q\n", c.Field, c.Old)
+			default:
+				fmt.Fprintf(bw, "      ~ %s: %q -> %q\n", c.Field, c.Old, c.New)
+			}
+		}
+	}
+	fmt.Fprintf(bw, "\n汇总: 新增 %d，移除 %d，变更 %d，未变化 %d\n",
+		len(r.Added), len(r.Removed), len(r.Changed), r.Unchanged)
+	return bw.Flush()
+}
+
+// String 返回 "型号名称 (ID)"
+func (d Device) String() string {
+	name := d.ModelName
+	if name == "" {
+		name = d.URL
+	}
+	return fmt.Sprintf("%s (%s)", name, d.ID)
+}
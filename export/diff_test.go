@@ -0,0 +1,118 @@
+package export
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/yangbin1322/go-gsmarena/crawler"
+)
+
+// diffNew 与 fixture 比较的新一次抓取结果
+var diffNew = phones{
+	// 最新的记录排在前面，较旧的记录不应被选中
+	{ModelName: "Apple iPhone 15 Pro Max", Brand: "Apple", ReleaseDate: "Released 2023, September 22",
+		URL: "https://www.gsmarena.com/apple_iphone_15_pro_max-12548.php", CrawledAt: "2024-06-01T08:00:00Z",
+		Specs: map[string]string{
+			"Announced":  "2023, September 12",
+			"Type":       "Li-Ion 4422 mAh, non-removable",
+			"Technology": "GSM / CDMA / HSPA / EVDO / LTE / 5G",
+			"Weight":     "221 g (7.80 oz)",
+		}},
+	{ModelName: "Apple iPhone 15 Pro Max", Brand: "Apple", ReleaseDate: "Released 2023, September 22",
+		URL: "https://www.gsmarena.com/apple_iphone_15_pro_max-12548.php", CrawledAt: "2024-05-01T08:00:00Z",
+		Specs: map[string]string{"Announced": "2023, September 12"}},
+	// 与旧数据源中最新的记录相同，只有抓取时间不同
+	{ModelName: "Samsung Galaxy S24 Ultra", Brand: "Samsung", ReleaseDate: "Released 2024, January 24",
+		URL: "https://www.gsmarena.com/samsung_galaxy_s24_ultra-12771.php", CrawledAt: "2024-06-01T08:00:00Z",
+		Specs: map[string]string{
+			"Announced":  "2024, January",
+			"Type":       "Li-Ion 5000 mAh, non-removable",
+			"Technology": "GSM / CDMA / HSPA / EVDO / LTE / 5G",
+		}},
+	{ModelName: "Google Pixel 8", Brand: "Google", URL: "https://www.gsmarena.com/google_pixel_8-12546.php", CrawledAt: "2024-06-01T08:00:00Z"},
+	{ModelName: "Apple iPhone 16", Brand: "Apple", URL: "https://www.gsmarena.com/apple_iphone_16-13317.php", CrawledAt: "2024-06-01T08:00:00Z"},
+}
+
+func TestDiff(t *testing.T) {
+	report, err := Diff(fixture, diffNew)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 按品牌、型号名称排序
+	wantAdded := []Device{
+		{ID: "13317", ModelName: "Apple iPhone 16", Brand: "Apple", URL: "https://www.gsmarena.com/apple_iphone_16-13317.php"},
+		{ID: "12546", ModelName: "Google Pixel 8", Brand: "Google", URL: "https://www.gsmarena.com/google_pixel_8-12546.php"},
+	}
+	if !reflect.DeepEqual(report.Added, wantAdded) {
+		t.Errorf("新增 = %+v\n期望 %+v", report.Added, wantAdded)
+	}
+	wantRemoved := []Device{{ID: "https://www.gsmarena.com/nokia_3310.php", ModelName: "Nokia 3310", Brand: "Nokia", URL: "https://www.gsmarena.com/nokia_3310.php"}}
+	if !reflect.DeepEqual(report.Removed, wantRemoved) {
+		t.Errorf("移除 = %+v\n期望 %+v", report.Removed, wantRemoved)
+	}
+	if report.Unchanged != 1 {
+		t.Errorf("未变化 = %d，期望 1（S24 Ultra 两边都取最新的记录）", report.Unchanged)
+	}
+
+	// 旧数据源中 iPhone 最新的记录是 2024-03-01 那条（4441 mAh、有价格）
+	if len(report.Changed) != 1 || report.Changed[0].ID != "12548" {
+		t.Fatalf("变更 = %+v，期望只有 12548", report.Changed)
+	}
+	wantChanges := []FieldChange{
+		{Field: "specs.Price", Kind: ChangeRemoved, Old: "€ 1,099.00"},
+		{Field: "specs.Type", Kind: ChangeChanged, Old: "Li-Ion 4441 mAh, non-removable", New: "Li-Ion 4422 mAh, non-removable"},
+		{Field: "specs.Weight", Kind: ChangeAdded, New: "221 g (7.80 oz)"},
+	}
+	if got := report.Changed[0].Changes; !reflect.DeepEqual(got, wantChanges) {
+		t.Errorf("字段变更 = %+v\n期望 %+v", got, wantChanges)
+	}
+}
+
+func TestDiffPhone(t *testing.T) {
+	before := crawler.Phone{ModelName: "Phone", Brand: "Acme", ReleaseDate: "2023", URL: "https://example.com/a-1.php",
+		CrawledAt: "2024-01-01T00:00:00Z", SchemaVersion: 1, Specs: map[string]string{"A": "1", "B": "2"}}
+	after := before
+	after.CrawledAt, after.SchemaVersion = "2024-06-01T00:00:00Z", 2
+	if changes := diffPhone(before, after); len(changes) != 0 {
+		t.Errorf("只有抓取时间和格式版本不同时 = %+v，期望没有变更", changes)
+	}
+
+	after.ModelName, after.ReleaseDate = "Phone Pro", "2024"
+	after.Specs = map[string]string{"A": "1", "B": "3", "C": ""}
+	want := []FieldChange{
+		{Field: "model_name", Kind: ChangeChanged, Old: "Phone", New: "Phone Pro"},
+		{Field: "release_date", Kind: ChangeChanged, Old: "2023", New: "2024"},
+		{Field: "specs.B", Kind: ChangeChanged, Old: "2", New: "3"},
+		// 值为空的字段也算新增
+		{Field: "specs.C", Kind: ChangeAdded},
+	}
+	if got := diffPhone(before, after); !reflect.DeepEqual(got, want) {
+		t.Errorf("字段变更 = %+v\n期望 %+v", got, want)
+	}
+}
+
+func TestDiffWriteText(t *testing.T) {
+	report, err := Diff(fixture, diffNew)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	if err := report.WriteText(&buf); err != nil {
+		t.Fatal(err)
+	}
+	out := buf.String()
+	for _, want := range []string{
+		"  + Apple iPhone 16 (13317)\n",
+		"  - Nokia 3310 (https://www.gsmarena.com/nokia_3310.php)\n",
+		"      ~ specs.Type: \"Li-Ion 4441 mAh, non-removable\" -> \"Li-Ion 4422 mAh, non-removable\"\n",
+		"      - specs.Price: \"€ 1,099.00\"\n",
+		"汇总: 新增 2，移除 1，变更 1，未变化 1\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("报告中缺少 %q:\n%s", want, out)
+		}
+	}
+}
@@ -0,0 +1,306 @@
+package export
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/yangbin1322/go-gsmarena/crawler"
+)
+
+// DefaultBulkBatchSize 直接推送时每个 _bulk 请求包含的文档数
+const DefaultBulkBatchSize = 500
+
+// SearchDocument Elasticsearch / OpenSearch 中的文档结构
+// 归一化后的数值字段直接展开到顶层，便于范围查询（如 battery_mah >= 5000）
+type SearchDocument struct {
+	DeviceID    string `json:"device_id"`
+	ModelName   string `json:"model_name"`
+	Brand       string `json:"brand"`
+	URL         string `json:"url"`
+	ReleaseDate string `json:"release_date"`
+	// Announced 发布日期（yyyy-MM-dd / yyyy-MM / yyyy，按页面精度），无法解析时省略
+	Announced string `json:"announced,omitempty"`
+	CrawledAt string `json:"crawled_at,omitempty"`
+
+	crawler.NormalizedSpecs
+
+	// SpecsText 所有规格参数值拼接的全文，用于关键字搜索
+	SpecsText string `json:"specs_text"`
+	// Specs 原始规格参数，只保存在 _source 中，不建索引（字段名含空格和点号，不适合动态映射）
+	Specs map[string]string `json:"specs"`
+}
+
+var announcedRe = regexp.MustCompile(`\b((?:19|20)\d{2})(?:,\s*([A-Z][a-z]+)(?:\s+(\d{1,2}))?)?`)
+
+// NewSearchDocument 将手机数据转换为搜索文档
+func NewSearchDocument(phone crawler.Phone) SearchDocument {
+	keys := make([]string, 0, len(phone.Specs))
+	for key := range phone.Specs {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	values := make([]string, 0, len(keys))
+	for _, key := range keys {
+		values = append(values, phone.Specs[key])
+	}
+
+	return SearchDocument{
+		DeviceID:        DeviceKey(phone),
+		ModelName:       phone.ModelName,
+		Brand:           phone.Brand,
+		URL:             phone.URL,
+		ReleaseDate:     phone.ReleaseDate,
+		Announced:       parseAnnounced(phone.Specs["Announced"]),
+		CrawledAt:       phone.CrawledAt,
+		NormalizedSpecs: crawler.Normalize(phone),
+		SpecsText:       strings.Join(values, "\n"),
+		Specs:           phone.Specs,
+	}
+}
+
+// parseAnnounced 解析 "2023, September 12" 这类日期，返回 2023-09-12 / 2023-09 / 2023
+func parseAnnounced(value string) string {
+	m := announcedRe.FindStringSubmatch(value)
+	if m == nil {
+		return ""
+	}
+	if m[2] == "" {
+		return m[1]
+	}
+	month, err := time.Parse("January", m[2])
+	if err != nil {
+		return m[1]
+	}
+	day, _ := strconv.Atoi(m[3])
+	if day == 0 {
+		return fmt.Sprintf("%s-%02d", m[1], month.Month())
+	}
+	return fmt.Sprintf("%s-%02d-%02d", m[1], month.Month(), day)
+}
+
+// IndexTemplate 返回索引模板（_index_template API 的请求体），匹配 <index>*
+func IndexTemplate(index string) map[string]any {
+	keyword := map[string]any{"type": "keyword"}
+	text := func() map[string]any {
+		return map[string]any{
+			"type":   "text",
+			"fields": map[string]any{"keyword": map[string]any{"type": "keyword", "ignore_above": 256}},
+		}
+	}
+	return map[string]any{
+		"index_patterns": []string{index + "*"},
+		"template": map[string]any{
+			"settings": map[string]any{"number_of_shards": 1},
+			"mappings": map[string]any{
+				"dynamic": "strict",
+				"properties": map[string]any{
+					"device_id":           keyword,
+					"model_name":          text(),
+					"brand":               keyword,
+					"url":                 keyword,
+					"release_date":        keyword,
+					"announced":           map[string]any{"type": "date", "format": "yyyy-MM-dd||yyyy-MM||yyyy"},
+					"crawled_at":          map[string]any{"type": "date"},
+					"release_year":        map[string]any{"type": "short"},
+					"display_size_inches": map[string]any{"type": "float"},
+					"display_width_px":    map[string]any{"type": "integer"},
+					"display_height_px":   map[string]any{"type": "integer"},
+					"battery_mah":         map[string]any{"type": "integer"},
+					"weight_grams":        map[string]any{"type": "float"},
+					"ram_gb":              map[string]any{"type": "float"},
+					"storage_gb":          map[string]any{"type": "float"},
+					"price_eur":           map[string]any{"type": "float"},
+					"has_5g":              map[string]any{"type": "boolean"},
+					"specs_text":          map[string]any{"type": "text"},
+					"specs":               map[string]any{"type": "object", "enabled": false},
+				},
+			},
+		},
+	}
+}
+
+// WriteBulk 将数据源写为 _bulk API 格式的 NDJSON（每个文档一行 index 操作 + 一行文档）
+// 文档 _id 为设备 ID，重复导入会覆盖同一文档；返回写入的文档数
+func WriteBulk(w io.Writer, src Source, index string) (int, error) {
+	bw := bufio.NewWriter(w)
+	count := 0
+	err := src.Each(func(phone crawler.Phone) error {
+		if err := writeBulkItem(bw, index, phone); err != nil {
+			return err
+		}
+		count++
+		return nil
+	})
+	if err != nil {
+		return count, err
+	}
+	return count, bw.Flush()
+}
+
+// writeBulkItem 写入一个文档的 index 操作
+func writeBulkItem(w io.Writer, index string, phone crawler.Phone) error {
+	doc := NewSearchDocument(phone)
+	action := map[string]any{"index": map[string]string{"_index": index, "_id": doc.DeviceID}}
+	encoder := json.NewEncoder(w)
+	if err := encoder.Encode(action); err != nil {
+		return fmt.Errorf("写入 bulk 操作失败: %w", err)
+	}
+	if err := encoder.Encode(doc); err != nil {
+		return fmt.Errorf("写入 bulk 文档失败: %w", err)
+	}
+	return nil
+}
+
+// BulkClient Elasticsearch / OpenSearch 客户端（只实现导出所需的 API）
+type BulkClient struct {
+	URL      string // 集群地址，如 http://localhost:9200
+	Username string // Basic 认证，为空时不认证
+	Password string
+	Client   *http.Client // 默认超时 60 秒
+}
+
+// BulkStats 直接推送的统计
+type BulkStats struct {
+	Indexed  int // 成功写入的文档数
+	Failed   int // 写入失败的文档数
+	Requests int // _bulk 请求数
+}
+
+// PutTemplate 创建（或覆盖）索引模板
+func (c *BulkClient) PutTemplate(ctx context.Context, name string, template map[string]any) error {
+	body, err := json.Marshal(template)
+	if err != nil {
+		return fmt.Errorf("索引模板序列化失败: %w", err)
+	}
+	_, err = c.do(ctx, http.MethodPut, "/_index_template/"+name, "application/json", body)
+	if err != nil {
+		return fmt.Errorf("创建索引模板失败: %w", err)
+	}
+	return nil
+}
+
+// Push 分批将数据源推送到 _bulk API
+func (c *BulkClient) Push(ctx context.Context, src Source, index string, batchSize int) (*BulkStats, error) {
+	if batchSize <= 0 {
+		batchSize = DefaultBulkBatchSize
+	}
+	stats := &BulkStats{}
+	var buf bytes.Buffer
+	pending := 0
+
+	flush := func() error {
+		if pending == 0 {
+			return nil
+		}
+		failed, err := c.bulk(ctx, buf.Bytes())
+		if err != nil {
+			return err
+		}
+		stats.Requests++
+		stats.Failed += failed
+		stats.Indexed += pending - failed
+		buf.Reset()
+		pending = 0
+		return nil
+	}
+
+	err := src.Each(func(phone crawler.Phone) error {
+		if err := writeBulkItem(&buf, index, phone); err != nil {
+			return err
+		}
+		pending++
+		if pending >= batchSize {
+			return flush()
+		}
+		return nil
+	})
+	if err == nil {
+		err = flush()
+	}
+	return stats, err
+}
+
+// bulk 发送一个 _bulk 请求，返回失败的文档数
+func (c *BulkClient) bulk(ctx context.Context, body []byte) (int, error) {
+	data, err := c.do(ctx, http.MethodPost, "/_bulk", "application/x-ndjson", body)
+	if err != nil {
+		return 0, fmt.Errorf("bulk 请求失败: %w", err)
+	}
+
+	var resp struct {
+		Errors bool `json:"errors"`
+		Items  []map[string]struct {
+			ID     string `json:"_id"`
+			Status int    `json:"status"`
+			Error  *struct {
+				Type   string `json:"type"`
+				Reason string `json:"reason"`
+			} `json:"error"`
+		} `json:"items"`
+	}
+	if err := json.Unmarshal(data, &resp); err != nil {
+		return 0, fmt.Errorf("解析 bulk 响应失败: %w", err)
+	}
+	if !resp.Errors {
+		return 0, nil
+	}
+
+	failed := 0
+	for _, item := range resp.Items {
+		for _, result := range item {
+			if result.Error != nil {
+				failed++
+				// 只打印前几条，避免映射错误时刷屏
+				if failed <= 5 {
+					log.Printf("[错误] 文档 %s 写入失败: %s: %s", result.ID, result.Error.Type, result.Error.Reason)
+				}
+			}
+		}
+	}
+	return failed, nil
+}
+
+// do 发送请求，非 2xx 响应返回错误
+func (c *BulkClient) do(ctx context.Context, method, path, contentType string, body []byte) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, method, strings.TrimRight(c.URL, "/")+path, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", contentType)
+	if c.Username != "" {
+		req.SetBasicAuth(c.Username, c.Password)
+	}
+
+	client := c.Client
+	if client == nil {
+		client = &http.Client{Timeout: 60 * time.Second}
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		if len(data) > 512 {
+			data = data[:512]
+		}
+		return nil, fmt.Errorf("HTTP %d: %s", resp.StatusCode, bytes.TrimSpace(data))
+	}
+	return data, nil
+}
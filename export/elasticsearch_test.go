@@ -0,0 +1,229 @@
+package export
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// bulkAction _bulk 请求中的 index 操作
+type bulkAction struct {
+	Index struct {
+		Index string `json:"_index"`
+		ID    string `json:"_id"`
+	} `json:"index"`
+}
+
+// parseBulk 解析 NDJSON 请求体为操作和文档，格式错误时测试失败
+func parseBulk(t *testing.T, body []byte) ([]bulkAction, []map[string]any) {
+	t.Helper()
+	if len(body) == 0 || body[len(body)-1] != '\n' {
+		t.Fatalf("bulk 请求体必须以换行结束: %q", body)
+	}
+	lines := strings.Split(strings.TrimSuffix(string(body), "\n"), "\n")
+	if len(lines)%2 != 0 {
+		t.Fatalf("bulk 请求体行数 = %d，期望操作和文档成对出现", len(lines))
+	}
+	var actions []bulkAction
+	var docs []map[string]any
+	for i := 0; i < len(lines); i += 2 {
+		var action bulkAction
+		var doc map[string]any
+		if err := json.Unmarshal([]byte(lines[i]), &action); err != nil {
+			t.Fatalf("第 %d 行不是操作: %v", i+1, err)
+		}
+		if err := json.Unmarshal([]byte(lines[i+1]), &doc); err != nil {
+			t.Fatalf("第 %d 行不是文档: %v", i+2, err)
+		}
+		actions = append(actions, action)
+		docs = append(docs, doc)
+	}
+	return actions, docs
+}
+
+func TestWriteBulk(t *testing.T) {
+	var buf bytes.Buffer
+	n, err := WriteBulk(&buf, fixture, "phones")
+	if err != nil {
+		t.Fatal(err)
+	}
+	actions, docs := parseBulk(t, buf.Bytes())
+	if n != 7 || len(docs) != 7 {
+		t.Fatalf("写入 %d 个文档，解析出 %d 个，期望 7", n, len(docs))
+	}
+
+	// 每行一个紧凑的 JSON 对象
+	scanner := bufio.NewScanner(&buf)
+	for scanner.Scan() {
+		if line := scanner.Text(); !json.Valid([]byte(line)) || strings.ContainsAny(line, "\t\r") {
+			t.Errorf("非法的 NDJSON 行: %q", line)
+		}
+	}
+
+	for i, id := range []string{"12548", "12771", "12548", "https://www.gsmarena.com/nokia_3310.php"} {
+		if actions[i].Index.Index != "phones" || actions[i].Index.ID != id {
+			t.Errorf("第 %d 个操作 = %+v，期望 _index=phones _id=%s", i+1, actions[i], id)
+		}
+	}
+
+	doc := docs[2]
+	for key, want := range map[string]any{
+		"device_id":    "12548",
+		"model_name":   "Apple iPhone 15 Pro Max",
+		"announced":    "2023-09-12",
+		"release_year": 2023.0,
+		"battery_mah":  4441.0,
+		"price_eur":    1099.0,
+		"has_5g":       true,
+		"specs_text":   "2023, September 12\n€ 1,099.00\nGSM / CDMA / HSPA / EVDO / LTE / 5G\nLi-Ion 4441 mAh, non-removable",
+	} {
+		if got := doc[key]; got != want {
+			t.Errorf("文档字段 %s = %#v，期望 %#v", key, got, want)
+		}
+	}
+	if specs, ok := doc["specs"].(map[string]any); !ok || specs["Type"] != "Li-Ion 4441 mAh, non-removable" {
+		t.Errorf("原始规格参数 = %#v", doc["specs"])
+	}
+
+	// 无法解析的字段省略，以免违反 strict 映射的类型
+	nokia := docs[3]
+	if nokia["announced"] != "2000" {
+		t.Errorf("announced = %#v，期望 2000", nokia["announced"])
+	}
+	for _, key := range []string{"battery_mah", "has_5g", "price_eur"} {
+		if _, ok := nokia[key]; ok {
+			t.Errorf("无法解析的字段 %s 不应出现在文档中", key)
+		}
+	}
+}
+
+func TestParseAnnounced(t *testing.T) {
+	for value, want := range map[string]string{
+		"2023, September 12":          "2023-09-12",
+		"2024, January":               "2024-01",
+		"2000, Q3":                    "2000",
+		"2019":                        "2019",
+		"Exp. announcement 2025, May": "2025-05",
+		"2022, Sept":                  "2022",
+		"Not announced yet":           "",
+		"":                            "",
+	} {
+		if got := parseAnnounced(value); got != want {
+			t.Errorf("parseAnnounced(%q) = %q，期望 %q", value, got, want)
+		}
+	}
+}
+
+// bulkServer 模拟 _bulk API：failID 对应的文档返回映射错误，status 非 0 时所有请求返回该状态码
+type bulkServer struct {
+	*httptest.Server
+	mu       sync.Mutex
+	bodies   [][]byte
+	failID   string
+	status   int
+	template []byte
+}
+
+func newBulkServer(t *testing.T, failID string) *bulkServer {
+	s := &bulkServer{failID: failID}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if user, pass, ok := r.BasicAuth(); !ok || user != "elastic" || pass != "changeme" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		if s.status != 0 {
+			w.WriteHeader(s.status)
+			fmt.Fprint(w, `{"error":"cluster_block_exception"}`)
+			return
+		}
+
+		switch {
+		case r.Method == http.MethodPut && r.URL.Path == "/_index_template/phones":
+			s.template = body
+			fmt.Fprint(w, `{"acknowledged":true}`)
+		case r.Method == http.MethodPost && r.URL.Path == "/_bulk":
+			if ct := r.Header.Get("Content-Type"); ct != "application/x-ndjson" {
+				t.Errorf("Content-Type = %s", ct)
+			}
+			s.bodies = append(s.bodies, body)
+			actions, _ := parseBulk(t, body)
+			var items []map[string]any
+			errors := false
+			for _, action := range actions {
+				result := map[string]any{"_id": action.Index.ID, "status": 201}
+				if action.Index.ID == s.failID {
+					errors = true
+					result["status"] = 400
+					result["error"] = map[string]string{"type": "mapper_parsing_exception", "reason": "failed to parse"}
+				}
+				items = append(items, map[string]any{"index": result})
+			}
+			json.NewEncoder(w).Encode(map[string]any{"errors": errors, "items": items})
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func TestBulkClientPush(t *testing.T) {
+	srv := newBulkServer(t, "12771")
+	client := &BulkClient{URL: srv.URL + "/", Username: "elastic", Password: "changeme"}
+
+	if err := client.PutTemplate(context.Background(), "phones", IndexTemplate("phones")); err != nil {
+		t.Fatal(err)
+	}
+	var template struct {
+		IndexPatterns []string `json:"index_patterns"`
+	}
+	if err := json.Unmarshal(srv.template, &template); err != nil || len(template.IndexPatterns) != 1 || template.IndexPatterns[0] != "phones*" {
+		t.Errorf("索引模板 = %s", srv.template)
+	}
+
+	// 7 个文档按每批 3 个分为 3 个请求，12771 的两条记录写入失败
+	stats, err := client.Push(context.Background(), fixture, "phones", 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := (BulkStats{Indexed: 5, Failed: 2, Requests: 3}); *stats != want {
+		t.Errorf("统计 = %+v，期望 %+v", *stats, want)
+	}
+	sizes := []int{}
+	for _, body := range srv.bodies {
+		_, docs := parseBulk(t, body)
+		sizes = append(sizes, len(docs))
+	}
+	if fmt.Sprint(sizes) != "[3 3 1]" {
+		t.Errorf("每批文档数 = %v，期望 [3 3 1]", sizes)
+	}
+}
+
+func TestBulkClientErrors(t *testing.T) {
+	srv := newBulkServer(t, "")
+	srv.status = http.StatusForbidden
+	client := &BulkClient{URL: srv.URL, Username: "elastic", Password: "changeme"}
+	stats, err := client.Push(context.Background(), fixture, "phones", 0)
+	if err == nil || !strings.Contains(err.Error(), "HTTP 403") || !strings.Contains(err.Error(), "cluster_block_exception") {
+		t.Errorf("err = %v，期望包含状态码和响应内容", err)
+	}
+	if stats.Indexed != 0 || stats.Requests != 0 {
+		t.Errorf("统计 = %+v，期望没有成功的请求", stats)
+	}
+
+	client.Password = "wrong"
+	if err := client.PutTemplate(context.Background(), "phones", IndexTemplate("phones")); err == nil {
+		t.Error("认证失败时应返回错误")
+	}
+}
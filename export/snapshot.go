@@ -0,0 +1,83 @@
+package export
+
+import (
+	"fmt"
+
+	"github.com/yangbin1322/go-gsmarena/crawler"
+	"github.com/yangbin1322/go-gsmarena/sink"
+)
+
+// SnapshotStats 快照导出统计
+type SnapshotStats struct {
+	Records    int // 数据源中的记录总数
+	Devices    int // 去重后的设备数（即写入快照的记录数）
+	Duplicates int // 被去除的重复记录数
+	Repeated   int // 出现过多次的设备数
+}
+
+// latest 设备最新记录的位置
+type latest struct {
+	crawledAt string
+	seq       int // 记录在数据源中的序号
+	count     int // 该设备出现的次数
+}
+
+// DeviceKey 返回记录的去重键：URL 中的设备 ID，无法解析时使用 URL
+func DeviceKey(phone crawler.Phone) string {
+	if id := crawler.DeviceID(phone.URL); id != "" {
+		return id
+	}
+	return phone.URL
+}
+
+// Snapshot 为每个设备保留最新的一条记录（按 crawled_at，相同时取数据源中靠后的记录）写入 dst
+// 遍历数据源两次：第一遍只记录每个设备最新记录的位置，第二遍写出，内存占用与设备数成正比而不是记录数
+// dst 由调用方关闭
+func Snapshot(src Source, dst sink.Sink) (*SnapshotStats, error) {
+	devices := make(map[string]*latest)
+	seq := 0
+	err := src.Each(func(phone crawler.Phone) error {
+		key := DeviceKey(phone)
+		if cur, ok := devices[key]; ok {
+			cur.count++
+			if phone.CrawledAt >= cur.crawledAt {
+				cur.crawledAt, cur.seq = phone.CrawledAt, seq
+			}
+		} else {
+			devices[key] = &latest{crawledAt: phone.CrawledAt, seq: seq, count: 1}
+		}
+		seq++
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	stats := &SnapshotStats{Records: seq, Devices: len(devices)}
+	for _, d := range devices {
+		if d.count > 1 {
+			stats.Repeated++
+			stats.Duplicates += d.count - 1
+		}
+	}
+
+	seq = 0
+	err = src.Each(func(phone crawler.Phone) error {
+		defer func() { seq++ }()
+		// 第二遍遍历时新追加的记录（数据源仍在写入）不在第一遍的统计中，跳过
+		if d, ok := devices[DeviceKey(phone)]; !ok || d.seq != seq {
+			return nil
+		}
+		if err := dst.Write(phone); err != nil {
+			return fmt.Errorf("写入快照失败: %s: %w", phone.URL, err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := dst.Flush(); err != nil {
+		return nil, fmt.Errorf("写入快照失败: %w", err)
+	}
+	return stats, nil
+}
@@ -0,0 +1,106 @@
+package export
+
+import (
+	"testing"
+
+	"github.com/yangbin1322/go-gsmarena/crawler"
+)
+
+// memSink 记录写入的数据
+type memSink struct {
+	phones  []crawler.Phone
+	flushed bool
+}
+
+func (s *memSink) Write(phone crawler.Phone) error {
+	s.phones = append(s.phones, phone)
+	return nil
+}
+
+func (s *memSink) Flush() error {
+	s.flushed = true
+	return nil
+}
+
+func (s *memSink) Close() error {
+	return nil
+}
+
+// fixture testdata 中的 JSONL 结果文件：三台设备共 7 条记录，包含空行和没有设备 ID 的旧 URL
+var fixture = JSONLSource{Path: "testdata/results.jsonl"}
+
+func TestSnapshot(t *testing.T) {
+	dst := &memSink{}
+	stats, err := Snapshot(fixture, dst)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := (SnapshotStats{Records: 7, Devices: 3, Duplicates: 4, Repeated: 3}); *stats != want {
+		t.Errorf("统计 = %+v，期望 %+v", *stats, want)
+	}
+	if !dst.flushed {
+		t.Error("快照写入后未刷新")
+	}
+
+	// 按数据源顺序输出每个设备 crawled_at 最新的记录，时间相同时取靠后的记录
+	want := []struct{ url, crawledAt, announced string }{
+		{"https://www.gsmarena.com/apple_iphone_15_pro_max-12548.php", "2024-03-01T08:00:00Z", "2023, September 12"},
+		{"https://www.gsmarena.com/nokia_3310.php", "2024-01-01T08:00:00Z", "2000, Q3"},
+		{"https://www.gsmarena.com/samsung_galaxy_s24_ultra-12771.php", "2024-01-01T08:00:00Z", "2024, January"},
+	}
+	if len(dst.phones) != len(want) {
+		t.Fatalf("快照记录数 = %d，期望 %d", len(dst.phones), len(want))
+	}
+	for i, w := range want {
+		p := dst.phones[i]
+		if p.URL != w.url || p.CrawledAt != w.crawledAt || p.Specs["Announced"] != w.announced {
+			t.Errorf("第 %d 条 = %s %s %q，期望 %s %s %q", i+1, p.URL, p.CrawledAt, p.Specs["Announced"], w.url, w.crawledAt, w.announced)
+		}
+	}
+	if v := dst.phones[1].SchemaVersion; v == 0 {
+		t.Error("旧记录未经 Migrate 补全格式版本")
+	}
+}
+
+// growingSource 第二次遍历时多出一条记录（模拟导出期间数据源仍在写入）
+type growingSource struct {
+	phones
+	extra  crawler.Phone
+	passes int
+}
+
+func (s *growingSource) Each(fn func(phone crawler.Phone) error) error {
+	s.passes++
+	if s.passes > 1 {
+		return append(s.phones, s.extra).Each(fn)
+	}
+	return s.phones.Each(fn)
+}
+
+func TestSnapshotIgnoresRecordsAppendedDuringExport(t *testing.T) {
+	old := crawler.Phone{URL: "https://www.gsmarena.com/test_phone-1.php", CrawledAt: "2024-01-01T08:00:00Z"}
+	newer := old
+	newer.CrawledAt = "2024-06-01T08:00:00Z"
+	src := &growingSource{phones: phones{old}, extra: newer}
+
+	dst := &memSink{}
+	stats, err := Snapshot(src, dst)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats.Records != 1 || len(dst.phones) != 1 || dst.phones[0].CrawledAt != old.CrawledAt {
+		t.Errorf("统计 = %+v，写入 %+v，期望只写入第一遍统计到的记录", stats, dst.phones)
+	}
+}
+
+func TestDeviceKey(t *testing.T) {
+	for url, want := range map[string]string{
+		"https://www.gsmarena.com/apple_iphone_15_pro_max-12548.php": "12548",
+		"https://www.gsmarena.com/nokia_3310.php":                    "https://www.gsmarena.com/nokia_3310.php",
+		"https://www.gsmarena.com/test_phone-beta.php":               "https://www.gsmarena.com/test_phone-beta.php",
+	} {
+		if got := DeviceKey(crawler.Phone{URL: url}); got != want {
+			t.Errorf("DeviceKey(%s) = %s，期望 %s", url, got, want)
+		}
+	}
+}
@@ -0,0 +1,103 @@
+// Package export 将抓取结果（JSONL 文件或 BoltDB 记录）导出为其他格式
+package export
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/yangbin1322/go-gsmarena/crawler"
+	"github.com/yangbin1322/go-gsmarena/sink"
+	"github.com/yangbin1322/go-gsmarena/storage"
+)
+
+// Source 抓取结果数据源，支持多次遍历（部分导出需要先统计字段再写入）
+type Source interface {
+	// Each 按顺序遍历所有记录，fn 返回错误时停止遍历
+	Each(fn func(phone crawler.Phone) error) error
+}
+
+// JSONLSource 从 JSONL 结果文件读取
+// Path 可以是 .jsonl 文件、压缩分片（.jsonl.gz / .jsonl.zst）或分片清单（.manifest.json，按清单顺序读取所有分片）
+type JSONLSource struct {
+	Path string
+}
+
+// Each 逐行解析 JSONL 文件，空行被忽略
+// 记录经 Phone.Migrate 补全格式版本，不会被标记为当前版本
+func (s JSONLSource) Each(fn func(phone crawler.Phone) error) error {
+	return s.EachLine(func(file string, lineNo int, line []byte) error {
+		var phone crawler.Phone
+		if err := json.Unmarshal(line, &phone); err != nil {
+			return fmt.Errorf("%s 第 %d 行解析失败: %w", file, lineNo, err)
+		}
+		phone.Migrate()
+		return fn(phone)
+	})
+}
+
+// EachLine 逐行遍历原始 JSON 数据（不解析），空行被忽略，file 为行所在的文件
+func (s JSONLSource) EachLine(fn func(file string, lineNo int, line []byte) error) error {
+	if !strings.HasSuffix(s.Path, ".manifest.json") {
+		return eachLine(s.Path, fn)
+	}
+
+	manifest, err := sink.ReadManifest(s.Path)
+	if err != nil {
+		return err
+	}
+	dir := filepath.Dir(s.Path)
+	for _, chunk := range manifest.Chunks {
+		if err := eachLine(filepath.Join(dir, chunk.File), fn); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// eachLine 逐行读取单个（可能压缩的）JSONL 文件
+func eachLine(path string, fn func(file string, lineNo int, line []byte) error) error {
+	f, err := sink.OpenChunk(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	// 单条记录可能较大，放宽行长度限制
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	lineNo := 0
+	for scanner.Scan() {
+		lineNo++
+		line := scanner.Bytes()
+		if len(line) == 0 {
+			continue
+		}
+		if err := fn(path, lineNo, line); err != nil {
+			return err
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("读取结果文件失败: %w", err)
+	}
+	return nil
+}
+
+// RecordSource 从 BoltDB 中保存的记录读取
+type RecordSource struct {
+	Store storage.RecordStore
+}
+
+// Each 遍历 BoltDB 中的所有记录（按 URL 排序），记录经 Phone.Migrate 补全格式版本
+func (s RecordSource) Each(fn func(phone crawler.Phone) error) error {
+	return s.Store.ForEachRecord(func(url string, record []byte) error {
+		var phone crawler.Phone
+		if err := json.Unmarshal(record, &phone); err != nil {
+			return fmt.Errorf("记录解析失败: %s: %w", url, err)
+		}
+		phone.Migrate()
+		return fn(phone)
+	})
+}
@@ -1,19 +1,21 @@
 package main
 
 import (
-	"encoding/json"
+	"context"
+	"errors"
+	"flag"
 	"fmt"
 	"log"
-	"net"
-	"net/http"
 	"os"
 	"os/signal"
 	"strings"
-	"sync"
 	"syscall"
 	"time"
 
-	"github.com/gocolly/colly/v2"
+	"github.com/yangbin1322/go-gsmarena/crawler"
+	"github.com/yangbin1322/go-gsmarena/proxy"
+	"github.com/yangbin1322/go-gsmarena/sink"
+	"github.com/yangbin1322/go-gsmarena/storage"
 )
 
 func init() {
@@ -24,31 +26,23 @@ func init() {
 
 }
 
-// Brand 品牌数据结构
-type Brand struct {
-	Name         string // 品牌名称
-	URL          string // 品牌页面 URL
-	DevicesCount int    // 设备数量
-}
-
-// Phone 手机数据结构
-type Phone struct {
-	ModelName   string            `json:"model_name"`   // 手机型号名称
-	Brand       string            `json:"brand"`        // 品牌
-	ReleaseDate string            `json:"release_date"` // 发布日期
-	URL         string            `json:"url"`          // 详情页 URL
-	Specs       map[string]string `json:"specs"`        // 规格参数（键值对）
-	CrawledAt   string            `json:"crawled_at"`   // 抓取时间
-}
-
 // 全局配置常量
 const (
 	// 代理 API 地址（请替换为实际的代理 API）
 	ProxyAPIURL = "http://api1.ydaili.cn/tools/MeasureApi.ashx?action=EAPI&secret=7030249B23199AAB03CEA8D01A066577167BC9BCF06EA186&number=10&orderId=SH20251130024239218&format=txt&split=3"
 
+	// 代理列表环境变量（逗号分隔），设置时优先于代理 API 使用
+	ProxyListEnv = "GSMARENA_PROXIES"
+
 	// 代理池最低阈值
 	MinProxyThreshold = 10
 
+	// 请求出口模式: proxy（只用代理池）/ direct（直连）/ hybrid（先直连，被封禁后切换到代理池）
+	ProxyMode = "proxy"
+
+	// 代理选择策略: weighted（按评分加权随机）/ round-robin / least-failure
+	ProxyStrategy = "weighted"
+
 	// BoltDB 数据库文件路径
 	DBPath = "crawler.db"
 
@@ -67,522 +61,282 @@ const (
 
 	// 请求超时时间（秒）
 	RequestTimeout = 15
-)
-
-// 全局变量
-var (
-	storage      Storage       // 持久化存储
-	proxyManager *ProxyManager // 代理管理器
-	outputFile   *os.File      // 输出文件句柄
-	outputMutex  sync.Mutex    // 输出文件写入锁
-)
 
-func main() {
-	log.SetFlags(log.LstdFlags | log.Lshortfile)
-	log.Println("========== GSMArena 爬虫启动 ==========")
+	// 中断后等待在途请求完成的最长时间（秒）
+	DrainTimeout = 30
 
-	// 1. 初始化持久化存储
-	var err error
-	storage, err = NewBoltStorage(DBPath, BucketName)
-	if err != nil {
-		log.Fatalf("初始化存储失败: %v", err)
-	}
-	defer storage.Close()
+	// 代理健康检查间隔（秒）
+	ProxyHealthInterval = 300
 
-	// 2. 初始化代理管理器
-	proxyManager = NewProxyManager(ProxyAPIURL, MinProxyThreshold)
-	if proxyManager.Count() == 0 {
-		log.Println("警告: 代理池为空，爬虫可能会因 IP 限制而失败")
-	}
+	// 代理 API 调用预算（0 表示不限）：每小时 / 每天最多调用次数、两次调用最小间隔（秒）、代理池上限
+	ProxyMaxCallsPerHour    = 30
+	ProxyMaxCallsPerDay     = 300
+	ProxyMinRefreshInterval = 10
+	ProxyMaxPoolSize        = 200
 
-	// 3. 打开输出文件
-	outputFile, err = os.OpenFile(OutputFile, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
-	if err != nil {
-		log.Fatalf("打开输出文件失败: %v", err)
-	}
-	defer outputFile.Close()
-
-	// ========== 阶段 1: 获取品牌列表 ==========
-	log.Println("========== 阶段 1: 获取品牌列表 ==========")
-	brands := fetchBrandList()
-	log.Printf("品牌列表获取完成，共 %d 个品牌", len(brands))
-
-	// ========== 阶段 2: 获取所有手机链接 ==========
-	log.Println("========== 阶段 2: 获取所有手机链接 ==========")
-	phoneLinks := fetchPhoneLinks(brands)
-	log.Printf("手机链接获取完成，共 %d 个手机链接", len(phoneLinks))
-
-	// ========== 阶段 3: 获取手机详情 ==========
-	log.Println("========== 阶段 3: 获取手机详情 ==========")
-	fetchPhoneDetails(phoneLinks)
-
-	// 4. 输出统计信息
-	printStats()
-
-	log.Println("========== 爬虫任务完成 ==========")
-}
-
-// createCollector 创建并配置 Colly 爬虫实例
-func createCollector() *colly.Collector {
-	c := colly.NewCollector(
-		// 限制爬取域名
-		colly.AllowedDomains("www.gsmarena.com", "gsmarena.com"),
-		// 启用异步模式
-		colly.Async(true),
-	)
-
-	// 配置 HTTP 传输层（设置超时和代理）
-	c.WithTransport(&http.Transport{
-		// 设置连接超时
-		DialContext: (&net.Dialer{
-			Timeout:   RequestTimeout * time.Second,
-			KeepAlive: 30 * time.Second,
-		}).DialContext,
-		// 最大空闲连接
-		MaxIdleConns:        100,
-		MaxIdleConnsPerHost: 10,
-		// 响应头超时
-		ResponseHeaderTimeout: RequestTimeout * time.Second,
-		// TLS 握手超时
-		TLSHandshakeTimeout: 10 * time.Second,
-	})
-
-	// 设置代理
-	c.SetProxyFunc(proxyManager.GetProxy)
-
-	// 设置限速规则
-	err := c.Limit(&colly.LimitRule{
-		DomainGlob:  "*gsmarena.com*",
-		Parallelism: Parallelism,
-		RandomDelay: time.Duration(MinDelay) * time.Millisecond,
-		Delay:       time.Duration(MaxDelay) * time.Millisecond,
-	})
-	if err != nil {
-		log.Fatalf("设置限速规则失败: %v", err)
-	}
-
-	// 设置 User-Agent
-	c.OnRequest(func(r *colly.Request) {
-		r.Headers.Set("User-Agent", "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36")
-		r.Headers.Set("Accept", "text/html,application/xhtml+xml,application/xml;q=0.9,image/webp,*/*;q=0.8")
-		r.Headers.Set("Accept-Language", "en-US,en;q=0.9")
-	})
-
-	return c
-}
-
-// fetchBrandList 阶段1: 获取所有品牌列表
-func fetchBrandList() []Brand {
-	brands := make([]Brand, 0)
-	var brandsMutex sync.Mutex
-
-	c := createCollector()
-
-	// 设置通用错误处理
-	setupErrorHandler(c)
-
-	// 解析品牌列表页
-	c.OnHTML(".st-text a", func(e *colly.HTMLElement) {
-		brandURL := e.Request.AbsoluteURL(e.Attr("href"))
-		brandName := strings.TrimSpace(e.DOM.Contents().First().Text())
-		devicesText := strings.TrimSpace(e.DOM.Find("span").Text())
-
-		devicesCount := 0
-		fmt.Sscanf(devicesText, "%d", &devicesCount)
-
-		log.Printf("[品牌] %s | %d devices | %s", brandName, devicesCount, brandURL)
-
-		brandsMutex.Lock()
-		brands = append(brands, Brand{
-			Name:         brandName,
-			URL:          brandURL,
-			DevicesCount: devicesCount,
-		})
-		brandsMutex.Unlock()
-	})
-
-	// 访问品牌列表页
-	startURL := "https://www.gsmarena.com/makers.php3"
-	if err := c.Visit(startURL); err != nil {
-		log.Printf("访问品牌列表页失败: %v", err)
-	}
+	// 品牌列表页是否使用粘滞代理会话（同一品牌由同一代理连续翻页，直到该代理失败）
+	StickyBrandSessions = true
+)
 
-	c.Wait()
-	return brands
+// commands 子命令（不带子命令时执行抓取）
+var commands = map[string]func(args []string) error{
+	"diff":     runDiff,
+	"export":   runExport,
+	"schema":   runSchema,
+	"validate": runValidate,
 }
 
-// fetchPhoneLinks 阶段2: 获取所有手机链接（使用URL构造方式翻页）
-func fetchPhoneLinks(brands []Brand) []string {
-	// 使用 map 进行快速去重
-	phoneLinkSet := make(map[string]bool)
-	var linksMutex sync.Mutex
-
-	// 用于统计每个品牌实际获取的链接数
-	brandLinkCount := make(map[string]int)
-	var brandCountMutex sync.Mutex
-
-	// ⭐ 为每个品牌创建独立的 collector，确保同步
-	for i, brand := range brands {
-		c := createCollector()
-		setupErrorHandler(c)
-
-		// 当前品牌名称（用于闭包）
-		currentBrandName := brand.Name
-
-		// 解析手机列表页
-		c.OnHTML(".makers", func(e *colly.HTMLElement) {
-			linkCount := 0
-
-			// 提取手机详情页链接
-			e.ForEach("li a", func(_ int, el *colly.HTMLElement) {
-				phoneURL := el.Request.AbsoluteURL(el.Attr("href"))
-
-				linksMutex.Lock()
-				if !phoneLinkSet[phoneURL] {
-					phoneLinkSet[phoneURL] = true
-					linkCount++
-					log.Printf("[发现] 手机链接 #%d: %s (品牌: %s)",
-						len(phoneLinkSet), phoneURL, currentBrandName)
-				}
-				linksMutex.Unlock()
-			})
-
-			// 更新品牌链接计数
-			if linkCount > 0 {
-				brandCountMutex.Lock()
-				brandLinkCount[currentBrandName] += linkCount
-				brandCountMutex.Unlock()
-
-				log.Printf("[本页统计] %s: 本页发现 %d 个新设备", currentBrandName, linkCount)
+func main() {
+	if len(os.Args) > 1 {
+		if cmd, ok := commands[os.Args[1]]; ok {
+			log.SetFlags(log.LstdFlags)
+			if err := cmd(os.Args[2:]); err != nil {
+				log.Fatalf("%v", err)
 			}
-		})
-
-		// 计算需要访问的总页数（每页50个设备）
-		totalPages := (brand.DevicesCount + 49) / 50
-		if totalPages == 0 {
-			totalPages = 1
+			return
 		}
+	}
 
-		log.Printf("[品牌开始] %d/%d: %s (预计 %d 页，官网显示 %d 台设备)",
-			i+1, len(brands), brand.Name, totalPages, brand.DevicesCount)
+	// 发现模式参数：指定任一项时使用搜索结果代替全量品牌遍历
+	searchKeyword := flag.String("search", "", "快速搜索关键字，如 \"galaxy s24\"（与 -finder 同时使用时作为型号名称条件）")
+	finderFilters := flag.String("finder", "", "Phone Finder 过滤条件，如 \"5G, 2024, >=5000 mAh\"")
+	// 输出配置：可重复指定，每条记录会写入所有输出
+	var sinks stringList
+	flag.Var(&sinks, "sink", "输出，格式为 类型[:路径]，可重复指定（jsonl/csv/sqlite/stdout），默认 jsonl:"+OutputFile)
+	flag.Parse()
+	if len(sinks) == 0 {
+		sinks = stringList{"jsonl:" + OutputFile}
+	}
 
-		// 从品牌URL中提取品牌标识和ID
-		brandSlug, brandID := extractBrandInfo(brand.URL)
+	log.SetFlags(log.LstdFlags | log.Lshortfile)
+	log.Println("========== GSMArena 爬虫启动 ==========")
 
-		if brandSlug == "" || brandID == "" {
-			log.Printf("[警告] 无法解析品牌URL: %s，跳过", brand.URL)
-			continue
+	// 捕获 Ctrl+C / SIGTERM：取消 ctx 后各阶段停止派发并等待在途请求，
+	// 再次发送信号时恢复默认行为（立即退出）
+	// done 在 run 返回后关闭，正常退出时不输出中断提示
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	done := make(chan struct{})
+	go func() {
+		select {
+		case <-ctx.Done():
+			stop()
+			log.Println("\n收到中断信号，正在优雅退出（再次按 Ctrl+C 强制退出）...")
+		case <-done:
 		}
+	}()
 
-		// 访问所有页面
-		for page := 1; page <= totalPages; page++ {
-			var pageURL string
-
-			if page == 1 {
-				pageURL = brand.URL
-			} else {
-				pageURL = fmt.Sprintf("https://www.gsmarena.com/%s-phones-f-%s-0-p%d.php",
-					brandSlug, brandID, page)
-			}
-
-			log.Printf("  [第 %d/%d 页] %s", page, totalPages, pageURL)
-
-			if err := c.Visit(pageURL); err != nil {
-				log.Printf("  [错误] 访问失败: %v", err)
-			}
+	err := run(ctx, *searchKeyword, *finderFilters, sinks)
+	close(done)
+	if err != nil {
+		log.Fatalf("%v", err)
+	}
+}
 
-			// 短暂延迟
-			time.Sleep(200 * time.Millisecond)
+// run 执行完整的抓取流程
+// 资源按打开的逆序关闭：先刷新并关闭输出，再关闭 BoltDB
+func run(ctx context.Context, searchKeyword, finderFilters string, sinkSpecs []string) error {
+	// 提前校验搜索参数，避免初始化代理后才发现表达式错误
+	var searchURL string
+	var err error
+	if searchKeyword != "" || finderFilters != "" {
+		searchURL, err = crawler.BuildSearchURL(searchKeyword, finderFilters)
+		if err != nil {
+			return fmt.Errorf("解析搜索参数失败: %w", err)
 		}
+	}
 
-		// ⭐ 关键：等待当前品牌的所有请求完成
-		c.Wait()
-
-		// 输出当前品牌的统计
-		brandCountMutex.Lock()
-		actualCount := brandLinkCount[brand.Name]
-		brandCountMutex.Unlock()
+	// 1. 初始化持久化存储
+	store, err := storage.NewBoltStorage(DBPath, BucketName)
+	if err != nil {
+		return fmt.Errorf("初始化存储失败: %w", err)
+	}
+	defer store.Close()
 
-		completionRate := 0.0
-		if brand.DevicesCount > 0 {
-			completionRate = float64(actualCount) / float64(brand.DevicesCount) * 100
+	// 2. 初始化代理管理器
+	mode, ok := proxy.ParseMode(ProxyMode)
+	if !ok {
+		return fmt.Errorf("未知的请求出口模式: %s", ProxyMode)
+	}
+	strategy, ok := proxy.ParseStrategy(ProxyStrategy)
+	if !ok {
+		return fmt.Errorf("未知的代理选择策略: %s", ProxyStrategy)
+	}
+	proxyManager := proxy.NewManager(ProxyAPIURL, MinProxyThreshold,
+		proxy.WithMode(mode),
+		proxy.WithStrategy(strategy),
+		proxy.WithProviders(proxy.Source{Provider: proxy.EnvProvider{Var: ProxyListEnv}, Priority: 1, Free: true}),
+		proxy.WithStore(store),
+		proxy.WithBudget(proxy.Budget{
+			MaxPerHour:  ProxyMaxCallsPerHour,
+			MaxPerDay:   ProxyMaxCallsPerDay,
+			MinInterval: ProxyMinRefreshInterval * time.Second,
+			MaxPoolSize: ProxyMaxPoolSize,
+		}),
+	)
+	if mode == proxy.ModeProxy && proxyManager.Count() == 0 {
+		log.Println("警告: 代理池为空，爬虫可能会因 IP 限制而失败")
+	}
+	// 退出前保存代理池（在关闭存储之前执行）
+	defer func() {
+		if err := proxyManager.Save(); err != nil {
+			log.Printf("警告: %v", err)
 		}
+	}()
+	go proxyManager.RunHealthCheck(ctx, proxy.HealthCheck{Interval: ProxyHealthInterval * time.Second})
 
-		status := "✓"
-		if actualCount < brand.DevicesCount {
-			status = "⚠"
+	// 3. 打开输出
+	output := sink.NewMulti()
+	defer func() {
+		if err := output.Close(); err != nil {
+			log.Printf("[错误] 关闭输出失败: %v", err)
 		}
-
-		log.Printf("[品牌完成] %s: 获取 %d/%d 个设备链接 (%.1f%%) %s",
-			brand.Name,
-			actualCount,
-			brand.DevicesCount,
-			completionRate,
-			status)
-
-		if actualCount < brand.DevicesCount {
-			missing := brand.DevicesCount - actualCount
-			log.Printf("  [注意] 缺少 %d 个设备链接", missing)
+	}()
+	for _, spec := range sinkSpecs {
+		s, err := sink.Open(spec)
+		if err != nil {
+			return fmt.Errorf("打开输出 %s 失败: %w", spec, err)
 		}
-
-		// ⭐ 品牌之间添加延迟，避免请求过快
-		time.Sleep(500 * time.Millisecond)
+		output.Add(spec, s)
+		log.Printf("输出: %s", spec)
 	}
 
-	// 最终统计
-	log.Printf("\n========== 链接获取总结 ==========")
-	totalExpected := 0
-	totalActual := len(phoneLinkSet)
-
-	for _, brand := range brands {
-		totalExpected += brand.DevicesCount
+	// 恢复检查：补写上次崩溃时已提交但未写入输出的记录
+	recovered, err := output.Recover(store)
+	if err != nil {
+		return fmt.Errorf("恢复检查失败: %w", err)
 	}
-
-	log.Printf("预期总数: %d", totalExpected)
-	log.Printf("实际获取: %d", totalActual)
-	log.Printf("完成率: %.2f%%", float64(totalActual)/float64(totalExpected)*100)
-	log.Printf("================================\n")
-
-	// 转换 map 为 slice
-	phoneLinks := make([]string, 0, len(phoneLinkSet))
-	for link := range phoneLinkSet {
-		phoneLinks = append(phoneLinks, link)
+	if recovered > 0 {
+		log.Printf("[恢复] 已处理 %d 条待导出记录", recovered)
 	}
 
-	return phoneLinks
-}
-
-// extractBrandInfo 从品牌URL中提取品牌标识和ID
-// 输入: https://www.gsmarena.com/doogee-phones-129.php
-// 输出: ("doogee", "129")
-func extractBrandInfo(url string) (brandSlug string, brandID string) {
-	parts := strings.Split(url, "/")
-	if len(parts) == 0 {
-		return "", ""
+	opts := []crawler.Option{
+		crawler.WithStorage(store),
+		crawler.WithProxyManager(proxyManager),
+		crawler.WithPhoneHandler(output.Write),
+		crawler.WithParallelism(Parallelism),
+		crawler.WithDelay(MinDelay*time.Millisecond, MaxDelay*time.Millisecond),
+		crawler.WithRequestTimeout(RequestTimeout * time.Second),
+		crawler.WithDrainTimeout(DrainTimeout * time.Second),
 	}
-
-	lastPart := parts[len(parts)-1]
-	lastPart = strings.TrimSuffix(lastPart, ".php")
-
-	// 格式: brand-phones-id
-	segments := strings.Split(lastPart, "-phones-")
-	if len(segments) != 2 {
-		return "", ""
+	if StickyBrandSessions {
+		opts = append(opts, crawler.WithStickySessions(crawler.SessionPerBrand))
 	}
-
-	brandSlug = segments[0]
-	brandID = segments[1]
-
-	return brandSlug, brandID
-}
-
-// fetchPhoneDetails 阶段3: 获取所有手机详情
-func fetchPhoneDetails(phoneLinks []string) {
-	c := createCollector()
-
-	// 设置通用错误处理
-	setupErrorHandler(c)
-
-	// 解析手机详情页
-	c.OnHTML("#specs-list", func(e *colly.HTMLElement) {
-		phoneURL := e.Request.URL.String()
-
-		// 去重检查
-		if storage.IsVisited(phoneURL) {
-			log.Printf("[跳过] 已访问: %s", phoneURL)
-			return
+	c := crawler.New(opts...)
+
+	var phoneLinks []string
+	if searchURL != "" {
+		// ========== 发现模式: 通过搜索结果获取手机链接 ==========
+		log.Println("========== 发现模式: 获取搜索结果 ==========")
+		phoneLinks, err = c.FetchSearchLinks(ctx, searchURL)
+		if interrupted(err) {
+			log.Printf("[中断] %v，已发现的 %d 个链接未抓取详情", err, len(phoneLinks))
+			return nil
 		}
-
-		// 提取手机名称
-		modelName := e.DOM.ParentsUntil("body").Find(".specs-phone-name-title").Text()
-		modelName = strings.TrimSpace(modelName)
-
-		// 提取品牌
-		brand := extractBrandFromURL(phoneURL)
-
-		// 提取规格参数
-		specs := make(map[string]string)
-		e.ForEach("table tr", func(_ int, row *colly.HTMLElement) {
-			key := strings.TrimSpace(row.ChildText(".ttl"))
-			value := strings.TrimSpace(row.ChildText(".nfo"))
-			if key != "" {
-				specs[key] = value
-			}
-		})
-
-		// 提取发布日期
-		releaseDate := specs["Released"]
-		if releaseDate == "" {
-			releaseDate = "Unknown"
+		if err != nil {
+			return fmt.Errorf("获取搜索结果失败: %w", err)
 		}
-
-		// 构建 Phone 对象
-		phone := Phone{
-			ModelName:   modelName,
-			Brand:       brand,
-			ReleaseDate: releaseDate,
-			URL:         phoneURL,
-			Specs:       specs,
-			CrawledAt:   time.Now().Format(time.RFC3339),
+		log.Printf("搜索结果获取完成，共 %d 个手机链接", len(phoneLinks))
+	} else {
+		// ========== 阶段 1: 获取品牌列表 ==========
+		log.Println("========== 阶段 1: 获取品牌列表 ==========")
+		brands, err := c.FetchBrandList(ctx)
+		if interrupted(err) {
+			log.Printf("[中断] %v", err)
+			return nil
 		}
-
-		// 保存数据
-		savePhone(phone)
-
-		// 标记为已访问
-		if err := storage.MarkVisited(phoneURL); err != nil {
-			log.Printf("[错误] 标记 URL 失败: %v", err)
-		} else {
-			log.Printf("[成功] 已抓取: %s", modelName)
+		if err != nil {
+			return fmt.Errorf("获取品牌列表失败: %w", err)
 		}
-	})
-
-	// 访问所有手机详情页
-	for i, phoneURL := range phoneLinks {
-		if storage.IsVisited(phoneURL) {
-			log.Printf("[跳过] 已访问 #%d/%d: %s", i+1, len(phoneLinks), phoneURL)
-			continue
+		log.Printf("品牌列表获取完成，共 %d 个品牌", len(brands))
+
+		// ========== 阶段 2: 获取所有手机链接 ==========
+		log.Println("========== 阶段 2: 获取所有手机链接 ==========")
+		phoneLinks, err = c.FetchPhoneLinks(ctx, brands)
+		if interrupted(err) {
+			log.Printf("[中断] %v，已发现的 %d 个链接未抓取详情", err, len(phoneLinks))
+			return nil
 		}
-		log.Printf("[进度] 正在获取手机 %d/%d", i+1, len(phoneLinks))
-		if err := c.Visit(phoneURL); err != nil {
-			log.Printf("访问手机详情页失败: %v", err)
+		if err != nil {
+			return fmt.Errorf("获取手机链接失败: %w", err)
 		}
+		log.Printf("手机链接获取完成，共 %d 个手机链接", len(phoneLinks))
 	}
 
-	c.Wait()
-}
-
-// setupErrorHandler 设置通用的错误处理和重试逻辑
-func setupErrorHandler(c *colly.Collector) {
-	// OnRequest: 请求发送前
-	c.OnRequest(func(r *colly.Request) {
-
-		log.Printf("[请求] %s", r.URL)
-
-	})
-
-	// OnError: 请求失败处理
-	c.OnError(func(r *colly.Response, err error) {
-		statusCode := r.StatusCode
-		requestURL := r.Request.URL.String()
-		requestID := r.Request.Headers.Get("X-Request-Timestamp")
-		proxyURL := proxyManager.GetRequestProxy(requestID)
-
-		log.Printf("[错误] URL=%s, StatusCode=%d, Error=%v, Proxy=%s",
-			requestURL, statusCode, err, proxyURL)
-
-		shouldRetry := false
-
-		switch {
-		case statusCode == 0:
-			log.Printf("[网络错误] StatusCode=0，需要重试: %v", err)
-			shouldRetry = true
-
-		case statusCode == 404:
-			log.Printf("[404] 页面不存在，跳过: %s", requestURL)
-			_ = storage.MarkVisited(requestURL)
-
-		case statusCode == 403 || statusCode == 429 || statusCode == 503:
-			log.Printf("[风控] 状态码 %d，剔除代理并重试", statusCode)
-			shouldRetry = true
-
-		case err != nil && (strings.Contains(err.Error(), "timeout") ||
-			strings.Contains(err.Error(), "connection refused") ||
-			strings.Contains(err.Error(), "EOF")):
-			log.Printf("[超时/连接失败] 剔除代理并重试")
-			shouldRetry = true
-
-		default:
-			log.Printf("[其他错误] 不重试: %v", err)
-		}
+	// ========== 阶段 3: 获取手机详情 ==========
+	log.Println("========== 阶段 3: 获取手机详情 ==========")
+	stats, err := c.FetchPhoneDetails(ctx, phoneLinks)
+	exhausted := errors.Is(err, proxy.ErrBudgetExhausted)
+	if interrupted(err) {
+		log.Printf("[中断] %v", err)
+	} else if err != nil && !exhausted {
+		return fmt.Errorf("获取手机详情失败: %w", err)
+	}
 
-		if shouldRetry {
-			if proxyURL != "" {
-				proxyManager.RemoveProxy(proxyURL)
-			}
-			if err := r.Request.Retry(); err != nil {
-				log.Printf("[重试失败] %s: %v", requestURL, err)
-			} else {
-				log.Printf("[已重试] %s", requestURL)
-			}
-		}
-	})
+	// 4. 输出统计信息
+	printStats(store, output, stats, proxyManager)
 
-	// OnResponse: 响应成功
-	c.OnResponse(func(r *colly.Response) {
-		log.Printf("[响应] %s (状态码: %d)", r.Request.URL, r.StatusCode)
-	})
-}
+	// 预算用完时未完成的链接留待下次运行，以错误退出便于定时任务发现
+	if exhausted {
+		return fmt.Errorf("获取手机详情失败: %w", err)
+	}
 
-// extractBrandFromURL 从 URL 中提取品牌名称
-// 例如: https://www.gsmarena.com/apple-phones-48.php -> "Apple"
-func extractBrandFromURL(url string) string {
-	parts := strings.Split(url, "/")
-	if len(parts) > 0 {
-		lastPart := parts[len(parts)-1]
-		// 移除 "-phones-xx.php" 后缀
-		brandPart := strings.Split(lastPart, "-phones-")
-		if len(brandPart) > 0 {
-			brand := strings.ReplaceAll(brandPart[0], "-", " ")
-			return strings.Title(strings.ToLower(brand))
-		}
+	if ctx.Err() == nil {
+		log.Println("========== 爬虫任务完成 ==========")
 	}
-	return "Unknown"
+	return nil
 }
 
-// savePhone 将手机数据保存为 JSONL 格式
-func savePhone(phone Phone) {
-	outputMutex.Lock()
-	defer outputMutex.Unlock()
+// interrupted 判断错误是否由中断信号（ctx 取消）引起
+func interrupted(err error) bool {
+	return errors.Is(err, context.Canceled)
+}
 
-	// 序列化为 JSON
-	data, err := json.Marshal(phone)
+// printStats 输出统计信息
+func printStats(store *storage.BoltStorage, output *sink.Multi, stats *crawler.DetailStats, proxyManager *proxy.Manager) {
+	// 获取已访问 URL 数量
+	count, err := store.GetStats()
 	if err != nil {
-		log.Printf("[错误] JSON 序列化失败: %v", err)
+		log.Printf("获取统计信息失败: %v", err)
 		return
 	}
 
-	// 写入文件（每行一个 JSON 对象）
-	if _, err := outputFile.Write(append(data, '\n')); err != nil {
-		log.Printf("[错误] 写入文件失败: %v", err)
-		return
+	log.Printf("========== 统计信息 ==========")
+	log.Printf("本次抓取: 成功 %d，失败 %d，跳过 %d，未完成 %d（共 %d）",
+		stats.Saved, stats.Failed, stats.Skipped, stats.Remaining, stats.Total)
+	log.Printf("已抓取 URL 数量: %d", count)
+	proxyStats := proxyManager.Stats()
+	egress := "使用代理池"
+	if proxyStats.Direct {
+		egress = "直连"
 	}
-
-	log.Printf("[保存] %s (%s)", phone.ModelName, phone.Brand)
-}
-
-// printStats 输出统计信息
-func printStats() {
-	// 获取已访问 URL 数量
-	if boltStorage, ok := storage.(*BoltStorage); ok {
-		count, err := boltStorage.GetStats()
-		if err != nil {
-			log.Printf("获取统计信息失败: %v", err)
-		} else {
-			log.Printf("========== 统计信息 ==========")
-			log.Printf("已抓取 URL 数量: %d", count)
-			log.Printf("剩余代理数量: %d", proxyManager.Count())
-			log.Printf("输出文件: %s", OutputFile)
-			log.Printf("==============================")
+	log.Printf("剩余代理数量: %d（可用 %d，冷却 %d，隔离 %d），出口模式: %s（当前%s）",
+		proxyStats.Total, proxyStats.Available, proxyStats.CoolingDown, proxyStats.Quarantined,
+		proxyStats.Mode, egress)
+	spend := proxyStats.Spend
+	log.Printf("代理 API 调用: 累计 %d 次（最近一小时 %d，最近 24 小时 %d），获得代理 %d 个，因预算跳过 %d 次",
+		spend.Calls, spend.LastHour, spend.LastDay, spend.Proxies, spend.Denied)
+	for i, s := range proxyManager.Scores() {
+		if i == 5 {
+			break
 		}
+		log.Printf("代理 %s: 评分 %.2f，成功率 %.0f%%，延迟 p50 %v / p95 %v",
+			proxy.Redact(s.URL), s.Score, s.SuccessRate*100, s.P50.Round(time.Millisecond), s.P95.Round(time.Millisecond))
+	}
+	for _, s := range output.Stats() {
+		log.Printf("输出 %s: 写入 %d，失败 %d", s.Name, s.Written, s.Failed)
 	}
+	log.Printf("==============================")
 }
 
-// init 初始化函数：设置信号处理（优雅退出）
-func init() {
-	// 捕获 Ctrl+C 信号
-	sigChan := make(chan os.Signal, 1)
-	signal.Notify(sigChan, os.Interrupt, syscall.SIGTERM)
+// stringList 可重复指定的字符串参数
+type stringList []string
 
-	go func() {
-		<-sigChan
-		log.Println("\n收到中断信号，正在优雅退出...")
-		if storage != nil {
-			storage.Close()
-		}
-		if outputFile != nil {
-			outputFile.Close()
-		}
-		os.Exit(0)
-	}()
+func (l *stringList) String() string {
+	return strings.Join(*l, ",")
+}
+
+func (l *stringList) Set(value string) error {
+	*l = append(*l, value)
+	return nil
 }
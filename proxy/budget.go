@@ -0,0 +1,211 @@
+package proxy
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+)
+
+// ErrBudgetExhausted 代理 API 调用次数已达 MaxPerHour 或 MaxPerDay 上限，本次不再请求代理来源
+// 可通过 errors.Is 判断；GetProxy 因此失败时，混合模式会回退为直连
+var ErrBudgetExhausted = errors.New("代理 API 调用预算已用完")
+
+// ErrRefreshThrottled 暂时不能补货：距上次付费调用不足 MinInterval、代理池已达 MaxPoolSize，
+// 或预算已用完但池中还有冷却中的代理。属于临时状态，稍后重试即可（冷却结束的代理会恢复分配）
+var ErrRefreshThrottled = errors.New("代理补货暂缓")
+
+// budgetLogInterval 预算拒绝日志的最小间隔（低于阈值时每次分配代理都会尝试补货，避免刷屏）
+const budgetLogInterval = time.Minute
+
+// Budget 代理 API 调用预算，零值字段表示不限
+// 只限制付费来源（Source.Free 为 false），本地文件、环境变量等免费来源不受限制
+type Budget struct {
+	MaxPerHour  int           // 最近一小时最多调用次数
+	MaxPerDay   int           // 最近 24 小时最多调用次数
+	MinInterval time.Duration // 两次调用的最小间隔
+	MaxPoolSize int           // 代理池最多保留的代理数量（包括冷却中和隔离的），达到后不再补货
+}
+
+// Spend 代理 API 调用统计（仅统计付费来源）
+// 存储实现 BudgetStore 时（如 storage.BoltStorage）与调用记录一同保存，重启后继续累计，预算不会因重启而重置
+type Spend struct {
+	Calls    int       // 累计调用次数（包括失败的调用）
+	LastHour int       // 最近一小时的调用次数
+	LastDay  int       // 最近 24 小时的调用次数
+	Proxies  int       // 累计新增的代理数量
+	Denied   int       // 因预算被拒绝的补货次数
+	LastCall time.Time // 最近一次调用的时间
+}
+
+// BudgetStore 可选接口：WithStore 设置的存储实现该接口时，预算状态（调用记录和统计）随之持久化
+type BudgetStore interface {
+	// SaveBudget 保存预算状态
+	SaveBudget(data []byte) error
+	// LoadBudget 读取保存的预算状态，未保存过时返回 nil
+	LoadBudget() ([]byte, error)
+}
+
+// savedBudget 持久化的预算状态
+type savedBudget struct {
+	Calls []time.Time `json:"calls"` // 最近 24 小时付费来源的调用时间
+	Spend Spend       `json:"spend"`
+}
+
+// WithBudget 设置代理 API 调用预算
+func WithBudget(b Budget) Option {
+	return func(pm *Manager) {
+		pm.budget = b
+	}
+}
+
+// reserve 检查预算并记录一次付费来源的调用，超出预算时返回 ErrBudgetExhausted 或 ErrRefreshThrottled
+func (pm *Manager) reserve(now time.Time) error {
+	pm.budgetLock.Lock()
+	defer pm.budgetLock.Unlock()
+
+	if err := pm.check(now); err != nil {
+		pm.spend.Denied++
+		return err
+	}
+	pm.calls = append(pm.calls, now)
+	pm.spend.Calls++
+	pm.spend.LastCall = now
+	// 调用前先落盘，调用后崩溃也会计入预算
+	pm.saveBudget()
+	return nil
+}
+
+// check 检查当前是否允许调用付费来源，调用方需持有 budgetLock
+func (pm *Manager) check(now time.Time) error {
+	// 只保留最近 24 小时的调用记录
+	dayAgo := now.Add(-24 * time.Hour)
+	i := 0
+	for i < len(pm.calls) && !pm.calls[i].After(dayAgo) {
+		i++
+	}
+	pm.calls = pm.calls[i:]
+
+	b := pm.budget
+	switch {
+	case b.MinInterval > 0 && !pm.spend.LastCall.IsZero() && now.Sub(pm.spend.LastCall) < b.MinInterval:
+		return fmt.Errorf("%w: 距上次调用不足 %v", ErrRefreshThrottled, b.MinInterval)
+	case b.MaxPerHour > 0 && countSince(pm.calls, now.Add(-time.Hour)) >= b.MaxPerHour:
+		return fmt.Errorf("%w: 最近一小时已调用 %d 次", ErrBudgetExhausted, b.MaxPerHour)
+	case b.MaxPerDay > 0 && len(pm.calls) >= b.MaxPerDay:
+		return fmt.Errorf("%w: 最近 24 小时已调用 %d 次", ErrBudgetExhausted, b.MaxPerDay)
+	}
+	return nil
+}
+
+// canRefresh 补货前的预检：代理池已满，或只有付费来源且预算不足时返回 ErrRefreshThrottled 或 ErrBudgetExhausted
+func (pm *Manager) canRefresh() error {
+	pm.lock.RLock()
+	full := pm.poolFull()
+	pm.lock.RUnlock()
+	if full {
+		return fmt.Errorf("%w: 代理池已达上限 %d", ErrRefreshThrottled, pm.budget.MaxPoolSize)
+	}
+	for _, src := range pm.sources {
+		if src.Free {
+			return nil
+		}
+	}
+	pm.budgetLock.Lock()
+	defer pm.budgetLock.Unlock()
+	return pm.check(time.Now())
+}
+
+// recordSpend 记录付费来源新增的代理数量
+func (pm *Manager) recordSpend(added int) {
+	pm.budgetLock.Lock()
+	defer pm.budgetLock.Unlock()
+	pm.spend.Proxies += added
+	pm.saveBudget()
+}
+
+// poolFull 代理池是否已达 MaxPoolSize，调用方需持有锁
+func (pm *Manager) poolFull() bool {
+	return pm.budget.MaxPoolSize > 0 && len(pm.proxies) >= pm.budget.MaxPoolSize
+}
+
+// Spend 返回代理 API 调用统计
+func (pm *Manager) Spend() Spend {
+	pm.budgetLock.Lock()
+	defer pm.budgetLock.Unlock()
+	now := time.Now()
+	spend := pm.spend
+	spend.LastHour = countSince(pm.calls, now.Add(-time.Hour))
+	spend.LastDay = countSince(pm.calls, now.Add(-24*time.Hour))
+	return spend
+}
+
+// saveBudget 保存预算状态，存储未实现 BudgetStore 时不做任何操作；失败时只记录日志，调用方需持有 budgetLock
+func (pm *Manager) saveBudget() {
+	bs, ok := pm.store.(BudgetStore)
+	if !ok {
+		return
+	}
+	data, err := json.Marshal(savedBudget{Calls: pm.calls, Spend: pm.spend})
+	if err == nil {
+		err = bs.SaveBudget(data)
+	}
+	if err != nil {
+		log.Printf("[代理] 保存调用预算失败: %v", err)
+	}
+}
+
+// loadBudget 恢复保存的预算状态（只保留最近 24 小时的调用记录）
+func (pm *Manager) loadBudget() {
+	bs, ok := pm.store.(BudgetStore)
+	if !ok {
+		return
+	}
+	data, err := bs.LoadBudget()
+	if err != nil {
+		log.Printf("警告: 读取保存的代理调用预算失败: %v", err)
+		return
+	}
+	if data == nil {
+		return
+	}
+	var saved savedBudget
+	if err := json.Unmarshal(data, &saved); err != nil {
+		log.Printf("警告: 忽略无法解析的代理调用预算: %v", err)
+		return
+	}
+
+	pm.budgetLock.Lock()
+	defer pm.budgetLock.Unlock()
+	dayAgo := time.Now().Add(-24 * time.Hour)
+	for _, t := range saved.Calls {
+		if t.After(dayAgo) {
+			pm.calls = append(pm.calls, t)
+		}
+	}
+	pm.spend = saved.Spend
+	pm.spend.LastHour, pm.spend.LastDay = 0, 0
+	if len(pm.calls) > 0 {
+		log.Printf("[代理] 恢复调用预算：最近 24 小时已调用 %d 次（累计 %d 次）", len(pm.calls), pm.spend.Calls)
+	}
+}
+
+// logBudget 记录预算拒绝，每 budgetLogInterval 最多一条
+func (pm *Manager) logBudget(err error) {
+	pm.budgetLock.Lock()
+	defer pm.budgetLock.Unlock()
+	if now := time.Now(); now.Sub(pm.budgetLogAt) >= budgetLogInterval {
+		pm.budgetLogAt = now
+		log.Printf("[代理] 跳过补货: %v（累计拒绝 %d 次）", err, pm.spend.Denied)
+	}
+}
+
+// countSince 统计 since 之后的调用次数（calls 按时间升序）
+func countSince(calls []time.Time, since time.Time) int {
+	n := 0
+	for i := len(calls) - 1; i >= 0 && calls[i].After(since); i-- {
+		n++
+	}
+	return n
+}
@@ -0,0 +1,117 @@
+package proxy
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+)
+
+// memStore 内存中的代理池和预算存储
+type memStore struct {
+	pool   map[string][]byte
+	budget []byte
+}
+
+func (s *memStore) SaveProxies(pool map[string][]byte) error {
+	s.pool = pool
+	return nil
+}
+
+func (s *memStore) LoadProxies(fn func(key string, data []byte) error) error {
+	for key, data := range s.pool {
+		if err := fn(key, data); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *memStore) SaveBudget(data []byte) error {
+	s.budget = data
+	return nil
+}
+
+func (s *memStore) LoadBudget() ([]byte, error) {
+	return s.budget, nil
+}
+
+func TestBudgetSurvivesRestart(t *testing.T) {
+	store := &memStore{}
+	budget := Budget{MaxPerHour: 2, MinInterval: time.Minute}
+	// 混合模式创建时不请求代理来源，便于直接检查预算
+	newManager := func() *Manager {
+		return NewManager("", 1, WithMode(ModeHybrid), WithStore(store), WithBudget(budget))
+	}
+
+	pm := newManager()
+	now := time.Now()
+	if err := pm.reserve(now.Add(-2 * time.Minute)); err != nil {
+		t.Fatalf("第一次调用被拒绝: %v", err)
+	}
+	pm.recordSpend(5)
+
+	// 重启后最小间隔仍然生效
+	pm = newManager()
+	if err := pm.reserve(now.Add(-30 * time.Second)); err != nil {
+		t.Fatalf("第二次调用被拒绝: %v", err)
+	}
+	if err := pm.reserve(now); !errors.Is(err, ErrRefreshThrottled) {
+		t.Fatalf("距上次调用不足 MinInterval 时 err = %v，期望 ErrRefreshThrottled", err)
+	}
+
+	// 重启后每小时的调用次数仍然生效
+	pm = newManager()
+	if err := pm.reserve(now.Add(time.Minute)); !errors.Is(err, ErrBudgetExhausted) {
+		t.Fatalf("超过 MaxPerHour 时 err = %v，期望 ErrBudgetExhausted", err)
+	}
+	spend := pm.Spend()
+	if spend.Calls != 2 || spend.LastHour != 2 || spend.Proxies != 5 {
+		t.Errorf("恢复的统计 = %+v，期望累计 2 次调用、最近一小时 2 次、5 个代理", spend)
+	}
+
+	// 超过 24 小时的调用记录不再计入
+	pm.budgetLock.Lock()
+	pm.calls = []time.Time{now.Add(-25 * time.Hour)}
+	pm.spend.LastCall = now.Add(-25 * time.Hour)
+	pm.saveBudget()
+	pm.budgetLock.Unlock()
+	pm = newManager()
+	if err := pm.reserve(now); err != nil {
+		t.Fatalf("旧调用记录过期后仍被拒绝: %v", err)
+	}
+}
+
+func TestRefreshThrottleIsRetryable(t *testing.T) {
+	for _, tc := range []struct {
+		name      string
+		budget    Budget
+		exhausted bool // 期望 ErrBudgetExhausted（否则期望 ErrRefreshThrottled）
+	}{
+		{"最小调用间隔", Budget{MinInterval: time.Hour}, false},
+		{"代理池已满", Budget{MaxPoolSize: 1}, false},
+		{"预算用完但有代理冷却中", Budget{MaxPerDay: 1}, false},
+		{"预算用完且没有代理", Budget{MaxPerDay: 1}, true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			p := &countingProvider{n: 1}
+			if tc.exhausted {
+				p.n = 0
+			}
+			pm := NewManager("", 1, WithProviders(Source{Provider: p}), WithBudget(tc.budget))
+			// 唯一的代理因限流进入冷却
+			for _, proxyURL := range pm.GetAll() {
+				pm.ReportFailure(proxyURL, FailureBan)
+			}
+
+			req, _ := http.NewRequest(http.MethodGet, "http://example.com/", nil)
+			_, err := pm.GetProxy(req)
+			if got := errors.Is(err, ErrBudgetExhausted); got != tc.exhausted {
+				t.Errorf("err = %v，ErrBudgetExhausted = %v，期望 %v", err, got, tc.exhausted)
+			}
+			if got := errors.Is(err, ErrRefreshThrottled); got == tc.exhausted {
+				t.Errorf("err = %v，ErrRefreshThrottled = %v，期望 %v", err, got, !tc.exhausted)
+			}
+		})
+	}
+}
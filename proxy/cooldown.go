@@ -0,0 +1,87 @@
+package proxy
+
+import (
+	"log"
+	"time"
+)
+
+// 冷却默认配置
+const (
+	// DefaultSoftCooldown 软失败（超时、限流）的首次冷却时间
+	DefaultSoftCooldown = 30 * time.Second
+	// DefaultHardCooldown 硬失败（连接被拒绝）的首次冷却时间
+	DefaultHardCooldown = 5 * time.Minute
+	// DefaultMaxCooldown 冷却时间上限
+	DefaultMaxCooldown = 30 * time.Minute
+	// DefaultMaxHardFailures 连续硬失败多少次后从池中移除
+	DefaultMaxHardFailures = 3
+)
+
+// Cooldown 代理失败后的冷却配置
+// 每次连续失败冷却时间翻倍（不超过 Max），请求成功后清零；
+// 冷却中的代理不会被分配，冷却结束后自动恢复
+type Cooldown struct {
+	Soft time.Duration // 软失败的首次冷却时间，默认 DefaultSoftCooldown
+	Hard time.Duration // 硬失败的首次冷却时间，默认 DefaultHardCooldown
+	Max  time.Duration // 冷却时间上限，默认 DefaultMaxCooldown
+	// MaxHardFailures 连续硬失败多少次后从池中移除（代理大概率已失效），默认 DefaultMaxHardFailures
+	MaxHardFailures int
+}
+
+// withDefaults 填充默认值
+func (c Cooldown) withDefaults() Cooldown {
+	if c.Soft <= 0 {
+		c.Soft = DefaultSoftCooldown
+	}
+	if c.Hard <= 0 {
+		c.Hard = DefaultHardCooldown
+	}
+	if c.Max <= 0 {
+		c.Max = DefaultMaxCooldown
+	}
+	if c.MaxHardFailures <= 0 {
+		c.MaxHardFailures = DefaultMaxHardFailures
+	}
+	return c
+}
+
+// WithCooldown 设置代理失败后的冷却配置
+func WithCooldown(c Cooldown) Option {
+	return func(pm *Manager) {
+		pm.cooldown = c.withDefaults()
+	}
+}
+
+// usable 代理当前是否可以分配（未隔离、不在冷却中且未过期）
+func (e *entry) usable(now time.Time) bool {
+	return !e.quarantined && !now.Before(e.coolUntil) && !e.expired(now)
+}
+
+// penalize 失败后让代理进入冷却，返回 false 表示代理应从池中移除，调用方需持有写锁
+func (pm *Manager) penalize(e *entry, kind FailureKind, now time.Time) bool {
+	e.strikes++
+	base := pm.cooldown.Soft
+	if kind == FailureHard {
+		e.hardStrikes++
+		if e.hardStrikes >= pm.cooldown.MaxHardFailures {
+			return false
+		}
+		base = pm.cooldown.Hard
+	}
+
+	// 冷却时间随连续失败次数指数增长
+	d := base
+	for i := 1; i < e.strikes && d < pm.cooldown.Max; i++ {
+		d *= 2
+	}
+	d = min(d, pm.cooldown.Max)
+	e.coolUntil = now.Add(d)
+	log.Printf("[代理] %s 连续失败 %d 次，冷却 %v", Redact(e.url), e.strikes, d)
+	return true
+}
+
+// forgive 请求成功后清除连续失败计数，调用方需持有写锁
+func (e *entry) forgive() {
+	e.strikes = 0
+	e.hardStrikes = 0
+}
@@ -0,0 +1,85 @@
+package proxy
+
+import (
+	"testing"
+	"time"
+)
+
+func TestPenalizeBackoff(t *testing.T) {
+	cooldown := Cooldown{Soft: 10 * time.Second, Hard: time.Minute, Max: 70 * time.Second, MaxHardFailures: 3}
+	for _, tc := range []struct {
+		name  string
+		kinds []FailureKind
+		want  []time.Duration // 每次失败后的冷却时长，0 表示移出代理池
+	}{
+		{"软失败逐次翻倍并封顶", []FailureKind{FailureBan, FailureError, FailureBan, FailureError, FailureBan},
+			[]time.Duration{10 * time.Second, 20 * time.Second, 40 * time.Second, 70 * time.Second, 70 * time.Second}},
+		{"硬失败达到上限后移除", []FailureKind{FailureHard, FailureHard, FailureHard},
+			[]time.Duration{time.Minute, 70 * time.Second, 0}},
+		// 连续失败次数包括软失败，硬失败以硬失败的首次冷却时间为基数
+		{"软硬失败交替", []FailureKind{FailureError, FailureHard, FailureError},
+			[]time.Duration{10 * time.Second, 70 * time.Second, 40 * time.Second}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			pm := testManager(StrategyRoundRobin)
+			pm.cooldown = cooldown
+			e := testEntry("a", 0, 0, 0, 0)
+			now := t0
+			for i, kind := range tc.kinds {
+				kept := pm.penalize(e, kind, now)
+				if want := tc.want[i]; want == 0 {
+					if kept {
+						t.Fatalf("第 %d 次失败后应移出代理池", i+1)
+					}
+					continue
+				}
+				if !kept {
+					t.Fatalf("第 %d 次失败后被移出代理池", i+1)
+				}
+				if got := e.coolUntil.Sub(now); got != tc.want[i] {
+					t.Errorf("第 %d 次失败后冷却 %v，期望 %v", i+1, got, tc.want[i])
+				}
+				// 冷却期间不可分配，结束后恢复
+				if e.usable(e.coolUntil.Add(-time.Nanosecond)) || !e.usable(e.coolUntil) {
+					t.Errorf("第 %d 次失败：冷却结束时间 %v 前后的可用状态不正确", i+1, e.coolUntil)
+				}
+				now = e.coolUntil
+			}
+		})
+	}
+}
+
+func TestCooldownResetsAfterSuccess(t *testing.T) {
+	pm := testManager(StrategyRoundRobin)
+	pm.cooldown = Cooldown{Soft: 10 * time.Second, Hard: time.Minute, Max: time.Hour, MaxHardFailures: 2}
+	e := testEntry("a", 0, 0, 0, 0)
+
+	pm.penalize(e, FailureBan, t0)
+	pm.penalize(e, FailureHard, t0)
+	e.forgive()
+
+	// 成功后重新从首次冷却时间开始，硬失败计数也清零
+	if !pm.penalize(e, FailureHard, t0) {
+		t.Fatal("成功后第一次硬失败不应移出代理池")
+	}
+	if got := e.coolUntil.Sub(t0); got != time.Minute {
+		t.Errorf("成功后首次硬失败冷却 %v，期望 1m", got)
+	}
+	e.forgive()
+	pm.penalize(e, FailureError, t0)
+	if got := e.coolUntil.Sub(t0); got != 10*time.Second {
+		t.Errorf("成功后首次软失败冷却 %v，期望 10s", got)
+	}
+}
+
+func TestReportSuccessClearsStrikes(t *testing.T) {
+	pm := testManager(StrategyRoundRobin, testEntry("http://1.2.3.4:8080", 0, 0, 0, 0))
+	pm.ReportFailure("http://1.2.3.4:8080", FailureBan)
+	pm.ReportFailure("http://1.2.3.4:8080", FailureBan)
+	pm.ReportSuccess("http://1.2.3.4:8080", time.Second)
+
+	e := pm.proxies[0]
+	if e.strikes != 0 || e.hardStrikes != 0 {
+		t.Errorf("成功后连续失败次数 = %d/%d，期望 0/0", e.strikes, e.hardStrikes)
+	}
+}
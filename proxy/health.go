@@ -0,0 +1,216 @@
+package proxy
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// 健康检查默认配置
+const (
+	DefaultHealthInterval    = 5 * time.Minute
+	DefaultHealthTimeout     = 10 * time.Second
+	DefaultHealthURL         = "https://www.gsmarena.com/"
+	DefaultHealthMarker      = "GSMArena.com"
+	DefaultHealthMaxFailures = 2
+	DefaultHealthParallelism = 10
+	// DefaultHealthUserAgent 探测请求使用的 User-Agent（与爬虫默认值一致，避免被当作机器人拦截）
+	DefaultHealthUserAgent = "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36"
+)
+
+// maxProbeBody 探测时最多读取的响应体大小
+const maxProbeBody = 1 << 20
+
+// HealthCheck 健康检查配置，零值字段使用默认值
+type HealthCheck struct {
+	Interval time.Duration // 两轮检查的间隔，默认 DefaultHealthInterval
+	Timeout  time.Duration // 单次探测超时，默认 DefaultHealthTimeout
+	URL      string        // 探测地址，默认 GSMArena 首页
+	// Marker 响应体中必须包含的内容，用于识别代理返回的验证页、广告页等非真实页面
+	Marker string
+	// MaxFailures 连续失败多少次后隔离，默认 DefaultHealthMaxFailures
+	MaxFailures int
+	// Parallelism 同时探测的代理数量，默认 DefaultHealthParallelism
+	Parallelism int
+	UserAgent   string
+}
+
+// Health 代理最近一次健康检查的结果
+type Health struct {
+	CheckedAt time.Time     // 检查时间，零值表示尚未检查
+	Healthy   bool          // 是否通过检查
+	Latency   time.Duration // 完整读取响应的耗时（通过检查时有效）
+	Error     string        // 未通过的原因
+	Failures  int           // 连续失败次数
+}
+
+// withDefaults 填充默认值
+func (hc HealthCheck) withDefaults() HealthCheck {
+	if hc.Interval <= 0 {
+		hc.Interval = DefaultHealthInterval
+	}
+	if hc.Timeout <= 0 {
+		hc.Timeout = DefaultHealthTimeout
+	}
+	if hc.URL == "" {
+		hc.URL = DefaultHealthURL
+	}
+	if hc.Marker == "" {
+		hc.Marker = DefaultHealthMarker
+	}
+	if hc.MaxFailures <= 0 {
+		hc.MaxFailures = DefaultHealthMaxFailures
+	}
+	if hc.Parallelism <= 0 {
+		hc.Parallelism = DefaultHealthParallelism
+	}
+	if hc.UserAgent == "" {
+		hc.UserAgent = DefaultHealthUserAgent
+	}
+	return hc
+}
+
+// RunHealthCheck 后台健康检查，阻塞直到 ctx 取消（通常以 go 启动）
+// 启动时立即检查一轮，之后每隔 Interval 探测池中所有代理（包括已隔离的）：
+// 连续失败 MaxFailures 次的代理被隔离，GetProxy 不再分配；隔离的代理恢复后重新启用
+func (pm *Manager) RunHealthCheck(ctx context.Context, hc HealthCheck) {
+	hc = hc.withDefaults()
+	ticker := time.NewTicker(hc.Interval)
+	defer ticker.Stop()
+
+	for {
+		pm.CheckHealth(ctx, hc)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// CheckHealth 对池中所有代理执行一轮健康检查
+func (pm *Manager) CheckHealth(ctx context.Context, hc HealthCheck) {
+	hc = hc.withDefaults()
+	proxies := pm.GetAll()
+	if len(proxies) == 0 {
+		return
+	}
+
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, hc.Parallelism)
+	for _, proxyURL := range proxies {
+		select {
+		case <-ctx.Done():
+			wg.Wait()
+			return
+		case sem <- struct{}{}:
+		}
+		wg.Add(1)
+		go func(proxyURL string) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			latency, err := probe(ctx, proxyURL, hc)
+			if ctx.Err() != nil {
+				// 中断导致的失败不计入
+				return
+			}
+			pm.recordHealth(proxyURL, latency, err, hc.MaxFailures)
+		}(proxyURL)
+	}
+	wg.Wait()
+
+	stats := pm.Stats()
+	log.Printf("[健康检查] 完成：共 %d 个代理，可用 %d，隔离 %d", stats.Total, stats.Available, stats.Quarantined)
+	pm.save()
+	if stats.Available < pm.minThreshold {
+		go pm.asyncRefresh()
+	}
+}
+
+// recordHealth 更新代理的检查结果，按结果隔离或恢复代理
+func (pm *Manager) recordHealth(proxyURL string, latency time.Duration, err error, maxFailures int) {
+	pm.lock.Lock()
+	defer pm.lock.Unlock()
+
+	e := pm.find(proxyURL)
+	if e == nil {
+		return
+	}
+	e.health.CheckedAt = time.Now()
+	if err == nil {
+		e.health.Healthy = true
+		e.health.Latency = latency
+		e.health.Error = ""
+		e.health.Failures = 0
+		if e.quarantined {
+			e.quarantined = false
+			log.Printf("[健康检查] 代理恢复，重新启用: %s (%v)", Redact(proxyURL), latency.Round(time.Millisecond))
+		}
+		return
+	}
+
+	e.health.Healthy = false
+	e.health.Error = err.Error()
+	e.health.Failures++
+	if !e.quarantined && e.health.Failures >= maxFailures {
+		e.quarantined = true
+		log.Printf("[健康检查] 代理连续 %d 次检查失败，已隔离: %s (%v)", e.health.Failures, Redact(proxyURL), err)
+	}
+}
+
+// Health 返回代理最近一次的健康检查结果，代理不在池中时返回 false
+func (pm *Manager) Health(proxyURL string) (Health, bool) {
+	pm.lock.RLock()
+	defer pm.lock.RUnlock()
+	if e := pm.find(proxyURL); e != nil {
+		return e.health, true
+	}
+	return Health{}, false
+}
+
+// probe 通过代理请求探测地址，检查可达性、状态码和页面内容，返回耗时
+func probe(ctx context.Context, proxyURL string, hc HealthCheck) (time.Duration, error) {
+	u, err := url.Parse(proxyURL)
+	if err != nil {
+		return 0, fmt.Errorf("代理地址无效: %w", err)
+	}
+	transport := &http.Transport{
+		Proxy:             http.ProxyURL(u),
+		DisableKeepAlives: true,
+	}
+	defer transport.CloseIdleConnections()
+	client := &http.Client{Transport: transport, Timeout: hc.Timeout}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, hc.URL, nil)
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("User-Agent", hc.UserAgent)
+
+	start := time.Now()
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxProbeBody))
+	latency := time.Since(start)
+	if err != nil {
+		return 0, fmt.Errorf("读取响应失败: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return 0, fmt.Errorf("HTTP %d", resp.StatusCode)
+	}
+	if !strings.Contains(string(body), hc.Marker) {
+		return 0, fmt.Errorf("响应内容不是 GSMArena 页面")
+	}
+	return latency, nil
+}
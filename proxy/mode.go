@@ -0,0 +1,141 @@
+package proxy
+
+import (
+	"log"
+	"time"
+)
+
+// Direct 直连请求在 Release 中返回的代理名称，ReportSuccess / ReportFailure 传入该值时记录直连的结果
+const Direct = "direct"
+
+// 混合模式默认配置
+const (
+	// DefaultHybridBans 直连连续收到多少次封禁响应后切换到代理池
+	DefaultHybridBans = 3
+	// DefaultDirectRetry 切换到代理池后多久再尝试恢复直连
+	DefaultDirectRetry = 30 * time.Minute
+)
+
+// Mode 请求出口模式
+type Mode int
+
+const (
+	// ModeProxy 只通过代理池请求（默认），没有可用代理时请求失败
+	ModeProxy Mode = iota
+	// ModeDirect 始终直连，不请求代理来源
+	ModeDirect
+	// ModeHybrid 先直连，出现封禁后切换到代理池，一段时间后再尝试恢复直连；
+	// 代理来源不可用（没有可用代理且刷新失败）时回退为直连
+	ModeHybrid
+)
+
+// String 返回模式名称
+func (m Mode) String() string {
+	switch m {
+	case ModeDirect:
+		return "direct"
+	case ModeHybrid:
+		return "hybrid"
+	default:
+		return "proxy"
+	}
+}
+
+// ParseMode 解析模式名称（proxy / direct / hybrid）
+func ParseMode(name string) (Mode, bool) {
+	switch name {
+	case "proxy", "":
+		return ModeProxy, true
+	case "direct":
+		return ModeDirect, true
+	case "hybrid":
+		return ModeHybrid, true
+	}
+	return ModeProxy, false
+}
+
+// WithMode 设置请求出口模式，默认 ModeProxy
+func WithMode(m Mode) Option {
+	return func(pm *Manager) {
+		pm.mode = m
+	}
+}
+
+// WithHybrid 设置混合模式的切换条件：直连连续 bans 次封禁后切换到代理池，retry 后再尝试直连
+func WithHybrid(bans int, retry time.Duration) Option {
+	return func(pm *Manager) {
+		if bans > 0 {
+			pm.hybridBans = bans
+		}
+		if retry > 0 {
+			pm.directRetry = retry
+		}
+	}
+}
+
+// useDirect 当前请求是否直连
+func (pm *Manager) useDirect() bool {
+	switch pm.mode {
+	case ModeDirect:
+		return true
+	case ModeHybrid:
+		pm.lock.Lock()
+		defer pm.lock.Unlock()
+		if pm.proxied && time.Since(pm.proxiedAt) >= pm.directRetry {
+			pm.proxied = false
+			pm.directBans = 0
+			log.Printf("[混合模式] 已使用代理池 %v，尝试恢复直连", pm.directRetry)
+		}
+		return !pm.proxied
+	}
+	return false
+}
+
+// poolInUse 当前是否使用代理池（直连时不补货，避免无谓地请求代理来源）
+func (pm *Manager) poolInUse() bool {
+	switch pm.mode {
+	case ModeDirect:
+		return false
+	case ModeHybrid:
+		pm.lock.RLock()
+		defer pm.lock.RUnlock()
+		return pm.proxied
+	}
+	return true
+}
+
+// fallbackDirect 混合模式下代理池不可用时回退为直连，返回 false 表示当前模式不允许回退
+func (pm *Manager) fallbackDirect(err error) bool {
+	if pm.mode != ModeHybrid {
+		return false
+	}
+	pm.lock.Lock()
+	defer pm.lock.Unlock()
+	if pm.proxied {
+		pm.proxied = false
+		pm.directBans = 0
+		log.Printf("[混合模式] 代理池不可用，回退为直连: %v", err)
+	}
+	return true
+}
+
+// reportDirect 记录直连请求的结果，混合模式下连续封禁达到阈值时切换到代理池，调用方需持有写锁
+func (pm *Manager) reportDirect(ok bool, kind FailureKind) {
+	if pm.mode != ModeHybrid || pm.proxied {
+		return
+	}
+	if ok {
+		pm.directBans = 0
+		return
+	}
+	// 超时等普通错误不算封禁信号
+	if kind != FailureBan {
+		return
+	}
+	pm.directBans++
+	if pm.directBans >= pm.hybridBans {
+		pm.proxied = true
+		pm.proxiedAt = time.Now()
+		log.Printf("[混合模式] 直连连续 %d 次被封禁，切换到代理池", pm.directBans)
+	}
+}
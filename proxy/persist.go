@@ -0,0 +1,259 @@
+package proxy
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// PoolStore 代理池的持久化存储（由 storage.BoltStorage 实现）
+// 重启后恢复代理池，避免每次启动都重新购买代理、丢失积累的评分
+type PoolStore interface {
+	// SaveProxies 用新的快照替换保存的代理池 (Key=代理地址的哈希，见 poolKey)
+	SaveProxies(pool map[string][]byte) error
+	// LoadProxies 遍历保存的代理池
+	LoadProxies(fn func(key string, data []byte) error) error
+}
+
+// WithStore 设置代理池的持久化存储：创建时恢复上次保存的代理池（丢弃已过期的，重新验证其余的，未通过的隔离），
+// 补货和健康检查后自动保存，退出前应调用 Save
+// 存储同时实现 BudgetStore 时，代理 API 调用预算的状态也一并保存和恢复
+func WithStore(s PoolStore) Option {
+	return func(pm *Manager) {
+		pm.store = s
+	}
+}
+
+// savedProxy 持久化的代理状态
+type savedProxy struct {
+	URL         string         `json:"url"` // 代理地址（含认证信息，重启后需要用它连接代理）
+	Provider    string         `json:"provider"`
+	ExpiresAt   time.Time      `json:"expires_at,omitzero"`
+	Quarantined bool           `json:"quarantined,omitempty"`
+	Health      Health         `json:"health"`
+	Successes   float64        `json:"successes"`
+	Failures    float64        `json:"failures"`
+	Bans        float64        `json:"bans"`
+	Updated     time.Time      `json:"updated,omitzero"`
+	Latencies   []savedLatency `json:"latencies,omitempty"`
+	CoolUntil   time.Time      `json:"cool_until,omitzero"`
+	Strikes     int            `json:"strikes,omitempty"`
+	HardStrikes int            `json:"hard_strikes,omitempty"`
+}
+
+// savedLatency 持久化的延迟样本
+type savedLatency struct {
+	At time.Time     `json:"at"`
+	D  time.Duration `json:"d"`
+}
+
+// poolKey 代理在存储中的键：代理地址的 SHA-256（前 16 字节），避免认证信息出现在键中
+// 地址相同但认证信息不同的代理（如按会话区分用户名的代理网关）对应不同的键
+func poolKey(proxyURL string) string {
+	sum := sha256.Sum256([]byte(proxyURL))
+	return hex.EncodeToString(sum[:16])
+}
+
+// expired 代理是否已过期
+func (e *entry) expired(now time.Time) bool {
+	return !e.expiresAt.IsZero() && !now.Before(e.expiresAt)
+}
+
+// pruneExpired 移除已过期的代理
+func (pm *Manager) pruneExpired() {
+	now := time.Now()
+	// 每次分配代理都会调用，先用读锁检查，避免无谓地争用写锁
+	pm.lock.RLock()
+	found := slices.ContainsFunc(pm.proxies, func(e *entry) bool { return e.expired(now) })
+	pm.lock.RUnlock()
+	if !found {
+		return
+	}
+
+	pm.lock.Lock()
+	defer pm.lock.Unlock()
+	for i := len(pm.proxies) - 1; i >= 0; i-- {
+		if e := pm.proxies[i]; e.expired(now) {
+			log.Printf("[代理] %s 已过期（%s）", Redact(e.url), e.expiresAt.Format(time.DateTime))
+			pm.remove(e.url)
+		}
+	}
+}
+
+// Save 将代理池（地址、过期时间、评分和冷却状态）及调用预算保存到存储，未设置存储时不做任何操作
+func (pm *Manager) Save() error {
+	if pm.store == nil {
+		return nil
+	}
+	pm.pruneExpired()
+
+	pm.lock.RLock()
+	pool := make(map[string][]byte, len(pm.proxies))
+	for _, e := range pm.proxies {
+		data, err := json.Marshal(e.snapshot())
+		if err != nil {
+			pm.lock.RUnlock()
+			return fmt.Errorf("序列化代理状态失败: %w", err)
+		}
+		pool[poolKey(e.url)] = data
+	}
+	pm.lock.RUnlock()
+
+	if err := pm.store.SaveProxies(pool); err != nil {
+		return err
+	}
+	pm.budgetLock.Lock()
+	pm.saveBudget()
+	pm.budgetLock.Unlock()
+	return nil
+}
+
+// save 保存代理池，失败时只记录日志
+func (pm *Manager) save() {
+	if err := pm.Save(); err != nil {
+		log.Printf("[代理] 保存代理池失败: %v", err)
+	}
+}
+
+// snapshot 导出代理的持久化状态，调用方需持有锁
+func (e *entry) snapshot() savedProxy {
+	s := &e.score
+	saved := savedProxy{
+		URL:         e.url,
+		Provider:    e.provider,
+		ExpiresAt:   e.expiresAt,
+		Quarantined: e.quarantined,
+		Health:      e.health,
+		Successes:   s.successes,
+		Failures:    s.failures,
+		Bans:        s.bans,
+		Updated:     s.updated,
+		CoolUntil:   e.coolUntil,
+		Strikes:     e.strikes,
+		HardStrikes: e.hardStrikes,
+	}
+	// 按写入顺序（从旧到新）导出环形缓冲区
+	for i := range latencySamples {
+		sample := s.latencies[(s.next+i)%latencySamples]
+		if !sample.at.IsZero() {
+			saved.Latencies = append(saved.Latencies, savedLatency{At: sample.at, D: sample.d})
+		}
+	}
+	return saved
+}
+
+// restore 从持久化状态恢复代理
+func restore(proxyURL string, saved savedProxy) *entry {
+	e := &entry{
+		url:         proxyURL,
+		provider:    saved.Provider,
+		expiresAt:   saved.ExpiresAt,
+		quarantined: saved.Quarantined,
+		health:      saved.Health,
+		coolUntil:   saved.CoolUntil,
+		strikes:     saved.Strikes,
+		hardStrikes: saved.HardStrikes,
+	}
+	e.score.successes = saved.Successes
+	e.score.failures = saved.Failures
+	e.score.bans = saved.Bans
+	e.score.updated = saved.Updated
+	for _, sample := range saved.Latencies {
+		e.score.latencies[e.score.next] = latencySample{at: sample.At, d: sample.D}
+		e.score.next = (e.score.next + 1) % latencySamples
+	}
+	return e
+}
+
+// load 恢复保存的代理池：丢弃已过期的代理，重新验证其余代理
+// 未通过验证的代理隔离后保留在池中（启动时的探测失败可能只是网络抖动或目标站点临时拦截），由健康检查恢复
+func (pm *Manager) load() {
+	now := time.Now()
+	var loaded []*entry
+	expired := 0
+	err := pm.store.LoadProxies(func(key string, data []byte) error {
+		var saved savedProxy
+		if err := json.Unmarshal(data, &saved); err != nil {
+			log.Printf("[代理] 忽略无法解析的代理记录 %s: %v", Redact(key), err)
+			return nil
+		}
+		// 旧版本以代理地址为键，没有 url 字段
+		proxyURL := saved.URL
+		if proxyURL == "" {
+			proxyURL = key
+		}
+		e := restore(proxyURL, saved)
+		if e.expired(now) {
+			expired++
+			return nil
+		}
+		loaded = append(loaded, e)
+		return nil
+	})
+	if err != nil {
+		log.Printf("警告: 读取保存的代理池失败: %v", err)
+		return
+	}
+	if len(loaded) == 0 {
+		if expired > 0 {
+			log.Printf("[代理] 保存的 %d 个代理均已过期", expired)
+		}
+		return
+	}
+
+	log.Printf("[代理] 正在验证上次保存的 %d 个代理（另有 %d 个已过期）...", len(loaded), expired)
+	failed := pm.revalidate(loaded)
+	pm.lock.Lock()
+	pm.proxies = append(pm.proxies, loaded...)
+	pm.lock.Unlock()
+	log.Printf("[代理] 从存储恢复 %d 个代理，其中 %d 个未通过验证，已隔离等待健康检查恢复", len(loaded), failed)
+}
+
+// revalidateCheck 启动时验证保存的代理使用的检查配置（并发数高于健康检查，减少启动等待）
+var revalidateCheck = HealthCheck{Parallelism: 50}
+
+// revalidate 并发探测代理，返回未通过验证的数量（保存的状态可能已失效，如代理服务商已回收 IP）
+// 通过的代理解除隔离，未通过的代理被隔离，记录失败原因
+func (pm *Manager) revalidate(entries []*entry) int {
+	hc := revalidateCheck.withDefaults()
+	ctx := context.Background()
+
+	var failed atomic.Int64
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, hc.Parallelism)
+	for _, e := range entries {
+		sem <- struct{}{}
+		wg.Add(1)
+		go func() {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			latency, err := probe(ctx, e.url, hc)
+			e.health.CheckedAt = time.Now()
+			if err != nil {
+				e.health.Healthy = false
+				e.health.Error = err.Error()
+				e.health.Failures++
+				e.quarantined = true
+				failed.Add(1)
+				log.Printf("[代理] 恢复的代理未通过验证，已隔离: %s (%v)", Redact(e.url), err)
+				return
+			}
+			e.health.Healthy = true
+			e.health.Latency = latency
+			e.health.Error = ""
+			e.health.Failures = 0
+			e.quarantined = false
+		}()
+	}
+	wg.Wait()
+	return int(failed.Load())
+}
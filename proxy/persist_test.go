@@ -0,0 +1,152 @@
+package proxy
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/yangbin1322/go-gsmarena/storage"
+)
+
+// probeProxy 模拟 HTTP 代理：校验认证信息后直接返回探测页面，healthy 为 false 时返回 503
+type probeProxy struct {
+	*httptest.Server
+	healthy atomic.Bool
+}
+
+func newProbeProxy(t *testing.T, user, pass string) *probeProxy {
+	p := &probeProxy{}
+	p.healthy.Store(true)
+	auth := "Basic " + base64.StdEncoding.EncodeToString([]byte(user+":"+pass))
+	p.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Proxy-Authorization") != auth {
+			w.WriteHeader(http.StatusProxyAuthRequired)
+			return
+		}
+		if !p.healthy.Load() {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		fmt.Fprint(w, "<title>GSMArena.com</title>")
+	}))
+	t.Cleanup(p.Close)
+	return p
+}
+
+// proxyURL 返回带认证信息的代理地址
+func (p *probeProxy) proxyURL(user, pass string) string {
+	return "http://" + user + ":" + pass + "@" + strings.TrimPrefix(p.URL, "http://")
+}
+
+func TestPoolPersistRoundTrip(t *testing.T) {
+	// 探测请求发往测试代理，不访问外网
+	hc := HealthCheck{URL: "http://probe.invalid/", Timeout: 2 * time.Second, MaxFailures: 1}
+	old := revalidateCheck
+	revalidateCheck = HealthCheck{URL: hc.URL, Timeout: hc.Timeout}
+	t.Cleanup(func() { revalidateCheck = old })
+
+	store, err := storage.NewBoltStorage(filepath.Join(t.TempDir(), "crawler.db"), "visited")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+
+	good := newProbeProxy(t, "alice", "s3cret-a")
+	flaky := newProbeProxy(t, "bob", "s3cret-b")
+	goodURL, flakyURL := good.proxyURL("alice", "s3cret-a"), flaky.proxyURL("bob", "s3cret-b")
+
+	// 混合模式创建时不请求代理来源
+	pm := NewManager("", 1, WithMode(ModeHybrid), WithStore(store))
+	expires := time.Now().Add(time.Hour).Truncate(time.Second)
+	pm.proxies = []*entry{
+		{url: goodURL, provider: "API example.com", expiresAt: expires},
+		{url: flakyURL, provider: "API example.com"},
+		{url: "http://127.0.0.1:1", expiresAt: time.Now().Add(-time.Minute)}, // 已过期
+	}
+	pm.ReportSuccess(goodURL, 300*time.Millisecond)
+	pm.ReportFailure(flakyURL, FailureBan)
+	if err := pm.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	// 键中不含认证信息
+	keys := 0
+	err = store.LoadProxies(func(key string, data []byte) error {
+		keys++
+		if strings.Contains(key, "s3cret") || strings.Contains(key, "@") || strings.Contains(key, "127.0.0.1") {
+			t.Errorf("存储键包含代理地址: %s", key)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if keys != 2 {
+		t.Errorf("保存了 %d 个代理，期望 2（已过期的不保存）", keys)
+	}
+
+	// 重启：通过验证的恢复原状，未通过的隔离但保留
+	flaky.healthy.Store(false)
+	pm = NewManager("", 1, WithMode(ModeHybrid), WithStore(store))
+	if got := pm.GetAll(); len(got) != 2 {
+		t.Fatalf("恢复了 %d 个代理，期望 2", len(got))
+	}
+	pm.lock.RLock()
+	g, f := pm.find(goodURL), pm.find(flakyURL)
+	pm.lock.RUnlock()
+	if g == nil || f == nil {
+		t.Fatal("恢复的代理地址与保存的不一致")
+	}
+	if g.quarantined || !g.health.Healthy || g.provider != "API example.com" || !g.expiresAt.Equal(expires) {
+		t.Errorf("通过验证的代理 = %+v", g)
+	}
+	if g.score.successes < 0.99 || g.score.percentile(time.Now(), 50, pm.halfLife) != 300*time.Millisecond {
+		t.Errorf("评分统计未恢复: %+v", g.score)
+	}
+	if !f.quarantined || f.health.Healthy || f.health.Error == "" || f.strikes != 1 {
+		t.Errorf("未通过验证的代理应被隔离并保留冷却状态: %+v", f)
+	}
+	if stats := pm.Stats(); stats.Quarantined != 1 {
+		t.Errorf("隔离数量 = %d，期望 1", stats.Quarantined)
+	}
+
+	// 健康检查在代理恢复后重新启用
+	flaky.healthy.Store(true)
+	pm.CheckHealth(context.Background(), hc)
+	if h, ok := pm.Health(flakyURL); !ok || !h.Healthy {
+		t.Errorf("恢复后的检查结果 = %+v", h)
+	}
+	if stats := pm.Stats(); stats.Quarantined != 0 {
+		t.Errorf("健康检查后隔离数量 = %d，期望 0", stats.Quarantined)
+	}
+}
+
+func TestPoolLoadLegacyKeys(t *testing.T) {
+	old := revalidateCheck
+	revalidateCheck = HealthCheck{URL: "http://probe.invalid/", Timeout: 2 * time.Second}
+	t.Cleanup(func() { revalidateCheck = old })
+
+	p := newProbeProxy(t, "alice", "pw")
+	proxyURL := p.proxyURL("alice", "pw")
+	// 旧版本以代理地址为键，值中没有 url 字段
+	store := &memStore{pool: map[string][]byte{proxyURL: []byte(`{"provider":"legacy","health":{}}`)}}
+	pm := NewManager("", 1, WithMode(ModeHybrid), WithStore(store))
+	if got := pm.GetAll(); len(got) != 1 || got[0] != proxyURL {
+		t.Fatalf("恢复的代理 = %v，期望 [%s]", got, Redact(proxyURL))
+	}
+
+	// 再次保存时改用哈希键
+	if err := pm.Save(); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := store.pool[poolKey(proxyURL)]; !ok || len(store.pool) != 1 {
+		t.Errorf("保存后的键 = %v，期望只有哈希键", store.pool)
+	}
+}
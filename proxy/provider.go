@@ -0,0 +1,292 @@
+package proxy
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	neturl "net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultProviderTimeout 代理 API 请求超时时间
+const DefaultProviderTimeout = 10 * time.Second
+
+// Provider 代理来源
+type Provider interface {
+	// Name 代理来源名称（用于日志）
+	Name() string
+	// Fetch 获取一批代理，返回的地址未必包含协议头，由 Manager 统一格式化（见 formatProxy）
+	Fetch(ctx context.Context) ([]Lease, error)
+}
+
+// Lease 代理来源返回的一个代理
+type Lease struct {
+	Addr string
+	// ExpiresAt 代理来源给出的过期时间，零值时使用 Source.TTL（也为零则不过期）
+	ExpiresAt time.Time
+}
+
+// leases 将地址列表转换为没有过期时间的 Lease
+func leases(addrs []string) []Lease {
+	out := make([]Lease, len(addrs))
+	for i, addr := range addrs {
+		out[i] = Lease{Addr: addr}
+	}
+	return out
+}
+
+// Source 代理池中的一个代理来源及其配额和优先级
+type Source struct {
+	Provider Provider
+	// Quota 池中最多保留该来源的代理数量，0 表示不限
+	Quota int
+	// Priority 优先级，数值大的先获取；补货时依次获取，可用代理达到阈值后不再请求低优先级的来源
+	Priority int
+	// Scheme 地址不带协议头时使用的协议（http / https / socks5 / socks5h），默认 http
+	Scheme string
+	// Free 免费来源（本地文件、环境变量等），不受 Budget 限制，也不计入调用统计
+	Free bool
+	// TTL 代理的有效期（从获取时算起），代理来源没有返回过期时间时使用，0 表示不过期
+	TTL time.Duration
+}
+
+// LineProvider 纯文本代理 API，每行一个代理（"IP:Port\r\n" 或 "IP:Port\n"）
+type LineProvider struct {
+	URL    string
+	Client *http.Client // 默认超时 DefaultProviderTimeout
+}
+
+// Name 返回代理来源名称
+func (p LineProvider) Name() string { return "API " + hostOf(p.URL) }
+
+// Fetch 请求代理 API 并按行解析
+func (p LineProvider) Fetch(ctx context.Context) ([]Lease, error) {
+	body, err := fetchBody(ctx, p.Client, p.URL)
+	if err != nil {
+		return nil, err
+	}
+	return leases(splitLines(string(body))), nil
+}
+
+// JSONProvider 返回 JSON 的代理 API，通过字段路径提取代理列表
+//
+// 路径以点号分隔，数组下标用数字，如 "data.list"、"result.0.proxies"。
+// 列表元素为字符串时直接作为代理地址；为对象时按 Host / Port 字段拼接，
+// 例如 {"data":{"list":[{"ip":"1.2.3.4","port":8080}]}} 对应 List="data.list"、Host="ip"、Port="port"。
+type JSONProvider struct {
+	URL  string
+	List string // 代理列表的路径，为空时响应本身就是列表
+	Host string // 元素中主机字段的路径，为空时元素为字符串
+	Port string // 元素中端口字段的路径
+	// Expire 元素中过期时间字段的路径，支持 Unix 时间戳（秒或毫秒）、RFC 3339、"2006-01-02 15:04:05"（本地时间），
+	// 以及剩余有效期（小于 maxRelativeExpire 的秒数，或 "5m"、"300s" 等时长）
+	Expire string
+	Client *http.Client // 默认超时 DefaultProviderTimeout
+}
+
+// Name 返回代理来源名称
+func (p JSONProvider) Name() string { return "JSON API " + hostOf(p.URL) }
+
+// Fetch 请求代理 API 并按字段路径提取代理
+func (p JSONProvider) Fetch(ctx context.Context) ([]Lease, error) {
+	body, err := fetchBody(ctx, p.Client, p.URL)
+	if err != nil {
+		return nil, err
+	}
+	var doc any
+	if err := json.Unmarshal(body, &doc); err != nil {
+		return nil, fmt.Errorf("解析代理 API 响应失败: %w", err)
+	}
+
+	list, ok := lookup(doc, p.List).([]any)
+	if !ok {
+		return nil, fmt.Errorf("代理 API 响应中 %q 不是列表", p.List)
+	}
+	proxies := make([]Lease, 0, len(list))
+	now := time.Now()
+	for _, item := range list {
+		var addr string
+		if p.Host == "" {
+			addr = scalar(item)
+		} else {
+			host, port := scalar(lookup(item, p.Host)), scalar(lookup(item, p.Port))
+			if host == "" || port == "" {
+				continue
+			}
+			addr = host + ":" + port
+		}
+		if addr == "" {
+			continue
+		}
+		lease := Lease{Addr: addr}
+		if p.Expire != "" {
+			lease.ExpiresAt = parseExpire(lookup(item, p.Expire), now)
+		}
+		proxies = append(proxies, lease)
+	}
+	return proxies, nil
+}
+
+// FileProvider 本地代理列表文件，每行一个代理，# 开头的行为注释
+// 每次补货时重新读取，修改文件后无需重启
+type FileProvider struct {
+	Path string
+}
+
+// Name 返回代理来源名称
+func (p FileProvider) Name() string { return "文件 " + p.Path }
+
+// Fetch 读取代理列表文件
+func (p FileProvider) Fetch(ctx context.Context) ([]Lease, error) {
+	data, err := os.ReadFile(p.Path)
+	if err != nil {
+		return nil, fmt.Errorf("读取代理列表文件失败: %w", err)
+	}
+	return leases(splitLines(string(data))), nil
+}
+
+// EnvProvider 环境变量中的代理列表，以逗号、空白或换行分隔；变量未设置时返回空列表
+type EnvProvider struct {
+	Var string
+}
+
+// Name 返回代理来源名称
+func (p EnvProvider) Name() string { return "环境变量 " + p.Var }
+
+// Fetch 读取环境变量
+func (p EnvProvider) Fetch(ctx context.Context) ([]Lease, error) {
+	return leases(strings.FieldsFunc(os.Getenv(p.Var), func(r rune) bool {
+		return r == ',' || r == ' ' || r == '\t' || r == '\r' || r == '\n'
+	})), nil
+}
+
+// fetchBody 发送 GET 请求并返回响应体，非 200 响应返回错误
+func fetchBody(ctx context.Context, client *http.Client, url string) ([]byte, error) {
+	if client == nil {
+		client = &http.Client{Timeout: DefaultProviderTimeout}
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("代理 API 地址无效: %s", hostOf(url))
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		// *url.Error 的消息包含完整 URL（可能带有 API 密钥），只保留主机部分
+		var uerr *neturl.Error
+		if errors.As(err, &uerr) {
+			err = uerr.Err
+		}
+		return nil, fmt.Errorf("请求代理 API %s 失败: %w", hostOf(url), err)
+	}
+	defer resp.Body.Close()
+
+	// 检查 HTTP 状态码
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("代理 API 返回非 200 状态码: %d", resp.StatusCode)
+	}
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("读取代理 API 响应失败: %w", err)
+	}
+	return body, nil
+}
+
+// splitLines 按行拆分代理列表，跳过空行和 # 注释
+func splitLines(text string) []string {
+	var lines []string
+	scanner := bufio.NewScanner(strings.NewReader(text))
+	for scanner.Scan() {
+		// 清理空格和回车符
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+// lookup 按点号分隔的路径取 JSON 值，路径为空时返回本身，不存在时返回 nil
+func lookup(v any, path string) any {
+	if path == "" {
+		return v
+	}
+	for _, key := range strings.Split(path, ".") {
+		switch node := v.(type) {
+		case map[string]any:
+			v = node[key]
+		case []any:
+			i, err := strconv.Atoi(key)
+			if err != nil || i < 0 || i >= len(node) {
+				return nil
+			}
+			v = node[i]
+		default:
+			return nil
+		}
+	}
+	return v
+}
+
+// scalar 将 JSON 字符串或数字转换为字符串
+func scalar(v any) string {
+	switch v := v.(type) {
+	case string:
+		return strings.TrimSpace(v)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	}
+	return ""
+}
+
+// maxRelativeExpire 小于该值的数字按剩余秒数处理（代理 API 常返回剩余有效期），否则按 Unix 时间戳处理
+const maxRelativeExpire = 1e9
+
+// parseExpire 解析过期时间字段，剩余有效期从 now 算起，无法解析时返回零值
+func parseExpire(v any, now time.Time) time.Time {
+	switch v := v.(type) {
+	case float64:
+		switch {
+		case v <= 0:
+			return time.Time{}
+		case v < maxRelativeExpire:
+			return now.Add(time.Duration(v * float64(time.Second)))
+		case v > 1e12:
+			// 超过 1e12 的按毫秒时间戳处理
+			return time.UnixMilli(int64(v))
+		}
+		return time.Unix(int64(v), 0)
+	case string:
+		v = strings.TrimSpace(v)
+		if n, err := strconv.ParseInt(v, 10, 64); err == nil {
+			return parseExpire(float64(n), now)
+		}
+		if t, err := time.Parse(time.RFC3339, v); err == nil {
+			return t
+		}
+		if t, err := time.ParseInLocation(time.DateTime, v, time.Local); err == nil {
+			return t
+		}
+		if d, err := time.ParseDuration(v); err == nil && d > 0 {
+			return now.Add(d)
+		}
+	}
+	return time.Time{}
+}
+
+// hostOf 返回 URL 的主机部分，避免在日志中打印 API 密钥等查询参数
+func hostOf(rawURL string) string {
+	if _, rest, ok := strings.Cut(rawURL, "://"); ok {
+		rawURL = rest
+	}
+	host, _, _ := strings.Cut(rawURL, "/")
+	host, _, _ = strings.Cut(host, "?")
+	return host
+}
@@ -0,0 +1,144 @@
+package proxy
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLookup(t *testing.T) {
+	var doc any
+	err := json.Unmarshal([]byte(`{
+		"data": {"list": [{"ip": "1.2.3.4", "port": 8080}], "total": 1},
+		"result": [{"proxies": ["5.6.7.8:3128"]}],
+		"empty": null
+	}`), &doc)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tc := range []struct {
+		path string
+		want string // 结果的 JSON 表示，不存在时为 null
+	}{
+		{"data.total", "1"},
+		{"data.list.0.ip", `"1.2.3.4"`},
+		{"data.list.0.port", "8080"},
+		{"result.0.proxies", `["5.6.7.8:3128"]`},
+		{"result.0.proxies.0", `"5.6.7.8:3128"`},
+		{"data.missing", "null"},
+		{"data.list.1", "null"},      // 下标越界
+		{"data.list.-1", "null"},     // 负数下标
+		{"data.list.ip", "null"},     // 数组的下标不是数字
+		{"data.total.value", "null"}, // 标量没有子字段
+		{"empty.value", "null"},      // null 没有子字段
+		{"missing.deeper.path", "null"},
+	} {
+		got, _ := json.Marshal(lookup(doc, tc.path))
+		if string(got) != tc.want {
+			t.Errorf("lookup(%q) = %s，期望 %s", tc.path, got, tc.want)
+		}
+	}
+	if lookup(doc, "") == nil {
+		t.Error("空路径应返回本身")
+	}
+}
+
+func TestParseExpire(t *testing.T) {
+	now := time.Date(2024, 6, 1, 8, 0, 0, 0, time.UTC)
+	local := time.Date(2024, 6, 1, 9, 30, 0, 0, time.Local)
+	for _, tc := range []struct {
+		name string
+		v    any
+		want time.Time
+	}{
+		{"秒级时间戳", float64(1717236000), time.Unix(1717236000, 0)},
+		{"毫秒时间戳", float64(1717236000123), time.UnixMilli(1717236000123)},
+		{"字符串时间戳", "1717236000", time.Unix(1717236000, 0)},
+		{"RFC 3339", "2024-06-01T10:00:00+02:00", time.Date(2024, 6, 1, 8, 0, 0, 0, time.UTC)},
+		{"本地时间", "2024-06-01 09:30:00", local},
+		{"剩余秒数", float64(300), now.Add(5 * time.Minute)},
+		{"字符串剩余秒数", " 600 ", now.Add(10 * time.Minute)},
+		{"时长", "5m", now.Add(5 * time.Minute)},
+		{"带单位的秒数", "90s", now.Add(90 * time.Second)},
+		{"零", float64(0), time.Time{}},
+		{"负数", float64(-5), time.Time{}},
+		{"负时长", "-5m", time.Time{}},
+		{"无法解析", "tomorrow", time.Time{}},
+		{"缺少字段", nil, time.Time{}},
+		{"布尔值", true, time.Time{}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := parseExpire(tc.v, now); !got.Equal(tc.want) {
+				t.Errorf("parseExpire(%v) = %v，期望 %v", tc.v, got, tc.want)
+			}
+		})
+	}
+}
+
+// jsonServer 返回固定 JSON 的代理 API
+func jsonServer(t *testing.T, body string) string {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, body)
+	}))
+	t.Cleanup(srv.Close)
+	return srv.URL
+}
+
+func TestJSONProviderFetch(t *testing.T) {
+	url := jsonServer(t, `{"code": 0, "data": {"list": [
+		{"ip": "1.2.3.4", "port": 8080, "expire": "5m"},
+		{"ip": "5.6.7.8", "port": "3128"},
+		{"ip": "9.9.9.9"},
+		{"port": 1080},
+		{"ip": " ", "port": 80}
+	]}}`)
+	p := JSONProvider{URL: url, List: "data.list", Host: "ip", Port: "port", Expire: "expire"}
+	start := time.Now()
+	leases, err := p.Fetch(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 缺少主机或端口的元素被跳过
+	if len(leases) != 2 || leases[0].Addr != "1.2.3.4:8080" || leases[1].Addr != "5.6.7.8:3128" {
+		t.Fatalf("代理 = %+v，期望 1.2.3.4:8080 和 5.6.7.8:3128", leases)
+	}
+	if exp := leases[0].ExpiresAt; exp.Before(start.Add(5*time.Minute)) || exp.After(time.Now().Add(5*time.Minute)) {
+		t.Errorf("过期时间 = %v，期望约 5 分钟后", exp)
+	}
+	if !leases[1].ExpiresAt.IsZero() {
+		t.Errorf("没有过期字段时 ExpiresAt = %v，期望零值", leases[1].ExpiresAt)
+	}
+}
+
+func TestJSONProviderStringList(t *testing.T) {
+	url := jsonServer(t, `[" 1.2.3.4:8080 ", "", 42, "user:pass@5.6.7.8:3128"]`)
+	leases, err := JSONProvider{URL: url}.Fetch(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var addrs []string
+	for _, l := range leases {
+		addrs = append(addrs, l.Addr)
+	}
+	// 数字按字符串处理（由 formatProxy 判断是否有效），空字符串被跳过
+	if got := strings.Join(addrs, ","); got != "1.2.3.4:8080,42,user:pass@5.6.7.8:3128" {
+		t.Errorf("代理 = %s", got)
+	}
+}
+
+func TestJSONProviderNotList(t *testing.T) {
+	url := jsonServer(t, `{"data": {"list": {"ip": "1.2.3.4"}}}`)
+	if _, err := (JSONProvider{URL: url, List: "data.list"}).Fetch(context.Background()); err == nil {
+		t.Error("列表路径指向对象时应返回错误")
+	}
+	if _, err := (JSONProvider{URL: url, List: "data.missing"}).Fetch(context.Background()); err == nil {
+		t.Error("列表路径不存在时应返回错误")
+	}
+}
@@ -0,0 +1,327 @@
+package proxy
+
+import (
+	"log"
+	"math"
+	"math/rand/v2"
+	"slices"
+	"time"
+)
+
+// 评分默认配置
+const (
+	// DefaultHalfLife 成功/失败计数的半衰期：一小时前的失败只按一半计入
+	DefaultHalfLife = 30 * time.Minute
+	// latencySamples 每个代理保留的最近延迟样本数
+	latencySamples = 32
+	// latencyReference 延迟评分的参考值：中位延迟等于该值时评分减半
+	latencyReference = 2 * time.Second
+	// banWeight 封禁（403/429 等）相对普通错误的权重
+	banWeight = 3
+)
+
+// FailureKind 请求失败的类型
+type FailureKind int
+
+const (
+	// FailureError 超时、连接中断等普通失败（软失败，冷却后恢复）
+	FailureError FailureKind = iota
+	// FailureBan 目标站点的封禁或限流响应（403 / 429 / 503，软失败，冷却后恢复）
+	FailureBan
+	// FailureHard 无法连接代理（连接被拒绝等），连续多次后代理从池中移除
+	FailureHard
+)
+
+// Strategy 代理选择策略
+type Strategy int
+
+const (
+	// StrategyWeighted 按评分加权随机选择（默认）
+	StrategyWeighted Strategy = iota
+	// StrategyRoundRobin 按顺序轮询，不考虑评分
+	StrategyRoundRobin
+	// StrategyLeastFailure 选择近期失败最少的代理，相同时选延迟低的
+	StrategyLeastFailure
+)
+
+// String 返回策略名称
+func (s Strategy) String() string {
+	switch s {
+	case StrategyRoundRobin:
+		return "round-robin"
+	case StrategyLeastFailure:
+		return "least-failure"
+	default:
+		return "weighted"
+	}
+}
+
+// ParseStrategy 解析策略名称（weighted / round-robin / least-failure）
+func ParseStrategy(name string) (Strategy, bool) {
+	switch name {
+	case "weighted", "":
+		return StrategyWeighted, true
+	case "round-robin", "roundrobin", "rr":
+		return StrategyRoundRobin, true
+	case "least-failure", "leastfailure":
+		return StrategyLeastFailure, true
+	}
+	return StrategyWeighted, false
+}
+
+// latencySample 一次成功请求的延迟
+type latencySample struct {
+	at time.Time
+	d  time.Duration
+}
+
+// score 代理的请求统计
+// 成功、失败、封禁计数按半衰期指数衰减，延迟只取最近的样本且样本超过两个半衰期后不再计入，
+// 因此代理过去的表现会逐渐被遗忘
+type score struct {
+	successes float64
+	failures  float64
+	bans      float64
+	updated   time.Time // 计数最后一次衰减的时间
+
+	latencies [latencySamples]latencySample // 环形缓冲区
+	next      int                           // 下一个写入位置
+}
+
+// decay 将计数衰减到 now
+func (s *score) decay(now time.Time, halfLife time.Duration) {
+	if !s.updated.IsZero() && now.After(s.updated) {
+		factor := math.Exp2(-float64(now.Sub(s.updated)) / float64(halfLife))
+		s.successes *= factor
+		s.failures *= factor
+		s.bans *= factor
+	}
+	s.updated = now
+}
+
+// success 记录一次成功请求
+func (s *score) success(now time.Time, latency time.Duration, halfLife time.Duration) {
+	s.decay(now, halfLife)
+	s.successes++
+	s.latencies[s.next] = latencySample{at: now, d: latency}
+	s.next = (s.next + 1) % latencySamples
+}
+
+// failure 记录一次失败请求
+func (s *score) failure(now time.Time, kind FailureKind, halfLife time.Duration) {
+	s.decay(now, halfLife)
+	if kind == FailureBan {
+		s.bans++
+	} else {
+		s.failures++
+	}
+}
+
+// percentile 返回窗口内延迟样本的百分位数（p 取 0~100），没有样本时返回 0
+func (s *score) percentile(now time.Time, p float64, halfLife time.Duration) time.Duration {
+	window := 2 * halfLife
+	samples := make([]time.Duration, 0, latencySamples)
+	for _, sample := range s.latencies {
+		if !sample.at.IsZero() && now.Sub(sample.at) <= window {
+			samples = append(samples, sample.d)
+		}
+	}
+	if len(samples) == 0 {
+		return 0
+	}
+	slices.Sort(samples)
+	idx := int(math.Ceil(p/100*float64(len(samples)))) - 1
+	return samples[max(idx, 0)]
+}
+
+// successRate 衰减后的成功率（加一平滑，没有记录的代理为 0.5）
+func (s *score) successRate(now time.Time, halfLife time.Duration) float64 {
+	s.decay(now, halfLife)
+	return (s.successes + 1) / (s.successes + s.failures + banWeight*s.bans + 2)
+}
+
+// value 综合评分：成功率乘以延迟系数，取值 (0, 1)
+func (s *score) value(now time.Time, halfLife time.Duration) float64 {
+	rate := s.successRate(now, halfLife)
+	p50 := s.percentile(now, 50, halfLife)
+	return rate / (1 + float64(p50)/float64(latencyReference))
+}
+
+// ProxyScore 代理的评分详情（用于统计输出和调试）
+type ProxyScore struct {
+	URL         string
+	Score       float64       // 综合评分
+	SuccessRate float64       // 衰减后的成功率
+	Successes   float64       // 衰减后的成功次数
+	Failures    float64       // 衰减后的失败次数
+	Bans        float64       // 衰减后的封禁次数
+	P50         time.Duration // 延迟中位数
+	P95         time.Duration // 延迟 95 分位
+	Quarantined bool
+	CoolUntil   time.Time // 冷却结束时间，零值或早于当前时间表示不在冷却中
+}
+
+// ReportSuccess 记录代理的一次成功请求及其延迟，并清除连续失败计数
+func (pm *Manager) ReportSuccess(proxyURL string, latency time.Duration) {
+	pm.lock.Lock()
+	defer pm.lock.Unlock()
+	if proxyURL == Direct {
+		pm.reportDirect(true, 0)
+		return
+	}
+	if e := pm.find(proxyURL); e != nil {
+		e.score.success(time.Now(), latency, pm.halfLife)
+		e.forgive()
+	}
+}
+
+// ReportFailure 记录代理的一次失败请求，代理进入冷却（见 Cooldown）
+// 连续硬失败达到上限的代理从池中移除；proxyURL 为 Direct 时记录直连的封禁（混合模式据此切换到代理池）
+func (pm *Manager) ReportFailure(proxyURL string, kind FailureKind) {
+	pm.lock.Lock()
+	defer pm.lock.Unlock()
+	if proxyURL == Direct {
+		pm.reportDirect(false, kind)
+		return
+	}
+	e := pm.find(proxyURL)
+	if e == nil {
+		return
+	}
+	now := time.Now()
+	e.score.failure(now, kind, pm.halfLife)
+	if !pm.penalize(e, kind, now) {
+		log.Printf("[代理] %s 连续 %d 次无法连接，移出代理池", Redact(proxyURL), e.hardStrikes)
+		pm.remove(proxyURL)
+	}
+	// 可用代理低于阈值时触发补货
+	if pm.available() < pm.minThreshold {
+		go pm.asyncRefresh()
+	}
+}
+
+// Scores 返回所有代理的评分，按评分从高到低排序
+func (pm *Manager) Scores() []ProxyScore {
+	pm.lock.Lock()
+	defer pm.lock.Unlock()
+
+	now := time.Now()
+	scores := make([]ProxyScore, 0, len(pm.proxies))
+	for _, e := range pm.proxies {
+		s := &e.score
+		scores = append(scores, ProxyScore{
+			URL:         e.url,
+			Score:       s.value(now, pm.halfLife),
+			SuccessRate: s.successRate(now, pm.halfLife),
+			Successes:   s.successes,
+			Failures:    s.failures,
+			Bans:        s.bans,
+			P50:         s.percentile(now, 50, pm.halfLife),
+			P95:         s.percentile(now, 95, pm.halfLife),
+			Quarantined: e.quarantined,
+			CoolUntil:   e.coolUntil,
+		})
+	}
+	slices.SortStableFunc(scores, func(a, b ProxyScore) int {
+		switch {
+		case a.Score > b.Score:
+			return -1
+		case a.Score < b.Score:
+			return 1
+		}
+		return 0
+	})
+	return scores
+}
+
+// find 按地址查找代理，调用方需持有锁
+func (pm *Manager) find(proxyURL string) *entry {
+	for _, e := range pm.proxies {
+		if e.url == proxyURL {
+			return e
+		}
+	}
+	return nil
+}
+
+// pick 按选择策略挑选一个可分配（未隔离、不在冷却中）的代理，没有可分配的代理时返回 nil，调用方需持有写锁
+func (pm *Manager) pick() *entry {
+	switch pm.strategy {
+	case StrategyWeighted:
+		return pm.pickWeighted()
+	case StrategyLeastFailure:
+		return pm.pickLeastFailure()
+	default:
+		return pm.pickRoundRobin()
+	}
+}
+
+// pickRoundRobin 按顺序轮询，跳过被隔离和冷却中的代理
+func (pm *Manager) pickRoundRobin() *entry {
+	now := time.Now()
+	for i := 0; i < len(pm.proxies); i++ {
+		e := pm.proxies[pm.currentIndex]
+		// 更新索引（循环）
+		pm.currentIndex = (pm.currentIndex + 1) % len(pm.proxies)
+		if e.usable(now) {
+			return e
+		}
+	}
+	return nil
+}
+
+// pickWeighted 按评分加权随机选择
+func (pm *Manager) pickWeighted() *entry {
+	now := time.Now()
+	weights := make([]float64, len(pm.proxies))
+	total := 0.0
+	for i, e := range pm.proxies {
+		if e.usable(now) {
+			weights[i] = e.score.value(now, pm.halfLife)
+			total += weights[i]
+		}
+	}
+	if total == 0 {
+		return nil
+	}
+
+	r := rand.Float64() * total
+	var last *entry
+	for i, e := range pm.proxies {
+		if weights[i] == 0 {
+			continue
+		}
+		last = e
+		if r < weights[i] {
+			return e
+		}
+		r -= weights[i]
+	}
+	// 浮点误差兜底
+	return last
+}
+
+// pickLeastFailure 选择衰减后失败（封禁按权重计）最少的代理，相同时选延迟中位数低的，再相同时按轮询顺序
+func (pm *Manager) pickLeastFailure() *entry {
+	now := time.Now()
+	var best *entry
+	var bestFailures float64
+	var bestLatency time.Duration
+	n := len(pm.proxies)
+	for i := 0; i < n; i++ {
+		e := pm.proxies[(pm.currentIndex+i)%n]
+		if !e.usable(now) {
+			continue
+		}
+		e.score.decay(now, pm.halfLife)
+		failures := e.score.failures + banWeight*e.score.bans
+		latency := e.score.percentile(now, 50, pm.halfLife)
+		if best == nil || failures < bestFailures || (failures == bestFailures && latency < bestLatency) {
+			best, bestFailures, bestLatency = e, failures, latency
+		}
+	}
+	if n > 0 {
+		pm.currentIndex = (pm.currentIndex + 1) % n
+	}
+	return best
+}
@@ -0,0 +1,248 @@
+package proxy
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+// t0 测试用的固定时间
+var t0 = time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+// approx 浮点数近似相等
+func approx(a, b float64) bool {
+	return math.Abs(a-b) < 1e-9
+}
+
+func TestScoreDecay(t *testing.T) {
+	const halfLife = 30 * time.Minute
+	for _, tc := range []struct {
+		name    string
+		updated time.Time
+		now     time.Time
+		factor  float64 // 期望的衰减系数
+	}{
+		{"一个半衰期", t0, t0.Add(halfLife), 0.5},
+		{"两个半衰期", t0, t0.Add(2 * halfLife), 0.25},
+		{"半个半衰期", t0, t0.Add(halfLife / 2), math.Sqrt2 / 2},
+		{"时间未变化", t0, t0, 1},
+		{"时间倒退不衰减", t0, t0.Add(-time.Hour), 1},
+		{"首次记录不衰减", time.Time{}, t0, 1},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			s := score{successes: 8, failures: 4, bans: 2, updated: tc.updated}
+			s.decay(tc.now, halfLife)
+			if !approx(s.successes, 8*tc.factor) || !approx(s.failures, 4*tc.factor) || !approx(s.bans, 2*tc.factor) {
+				t.Errorf("衰减后 = %.4f/%.4f/%.4f，期望系数 %.4f", s.successes, s.failures, s.bans, tc.factor)
+			}
+			if !s.updated.Equal(tc.now) {
+				t.Errorf("updated = %v，期望 %v", s.updated, tc.now)
+			}
+		})
+	}
+}
+
+func TestScoreSuccessRate(t *testing.T) {
+	const halfLife = time.Hour
+	for _, tc := range []struct {
+		name string
+		fn   func(s *score)
+		want float64
+	}{
+		{"没有记录", func(s *score) {}, 0.5},
+		{"一次成功", func(s *score) { s.success(t0, time.Second, halfLife) }, 2.0 / 3},
+		{"一次失败", func(s *score) { s.failure(t0, FailureError, halfLife) }, 1.0 / 3},
+		// 封禁按 banWeight 计入
+		{"一次封禁", func(s *score) { s.failure(t0, FailureBan, halfLife) }, 1.0 / 5},
+		{"成功后一个半衰期再失败", func(s *score) {
+			s.success(t0.Add(-halfLife), time.Second, halfLife)
+			s.failure(t0, FailureError, halfLife)
+		}, 1.5 / 3.5},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var s score
+			tc.fn(&s)
+			if got := s.successRate(t0, halfLife); !approx(got, tc.want) {
+				t.Errorf("successRate = %.4f，期望 %.4f", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestScorePercentile(t *testing.T) {
+	const halfLife = 30 * time.Minute
+	var s score
+	// 超过两个半衰期的样本不计入
+	s.success(t0.Add(-2*halfLife-time.Second), 10*time.Second, halfLife)
+	for i := 1; i <= 4; i++ {
+		s.success(t0, time.Duration(i)*100*time.Millisecond, halfLife)
+	}
+	if got := s.percentile(t0, 50, halfLife); got != 200*time.Millisecond {
+		t.Errorf("p50 = %v，期望 200ms", got)
+	}
+	if got := s.percentile(t0, 95, halfLife); got != 400*time.Millisecond {
+		t.Errorf("p95 = %v，期望 400ms", got)
+	}
+	if got := s.percentile(t0.Add(3*halfLife), 50, halfLife); got != 0 {
+		t.Errorf("样本全部过期后 p50 = %v，期望 0", got)
+	}
+
+	// 环形缓冲区只保留最近 latencySamples 个样本
+	var full score
+	for i := range latencySamples + 8 {
+		full.success(t0, time.Duration(i+1)*time.Millisecond, halfLife)
+	}
+	if got := full.percentile(t0, 0, halfLife); got != 9*time.Millisecond {
+		t.Errorf("最小样本 = %v，期望 9ms（最早的 8 个已被覆盖）", got)
+	}
+}
+
+func TestScoreValue(t *testing.T) {
+	const halfLife = time.Hour
+	var fast, slow score
+	fast.success(t0, 0, halfLife)
+	slow.success(t0, latencyReference, halfLife)
+	// 中位延迟等于参考值时评分减半
+	if got, want := slow.value(t0, halfLife), fast.value(t0, halfLife)/2; !approx(got, want) {
+		t.Errorf("慢代理评分 = %.4f，期望 %.4f", got, want)
+	}
+}
+
+// entryTime 测试代理的统计时间（选择策略使用 time.Now，所有代理共用同一时间，衰减后计数才能相等）
+var entryTime = time.Now()
+
+// testEntry 构造带请求统计的代理
+func testEntry(url string, successes, failures, bans float64, p50 time.Duration) *entry {
+	now := entryTime
+	e := &entry{url: url}
+	e.score = score{successes: successes, failures: failures, bans: bans, updated: now}
+	if p50 > 0 {
+		e.score.latencies[0] = latencySample{at: now, d: p50}
+		e.score.next = 1
+	}
+	return e
+}
+
+// testManager 构造只包含指定代理的管理器
+func testManager(strategy Strategy, entries ...*entry) *Manager {
+	return &Manager{
+		strategy: strategy,
+		halfLife: DefaultHalfLife,
+		cooldown: Cooldown{}.withDefaults(),
+		proxies:  entries,
+		sessions: make(map[string]string),
+	}
+}
+
+// picks 连续挑选 n 次，返回挑选到的代理地址（没有可分配的代理时为空字符串）
+func picks(pm *Manager, n int) []string {
+	out := make([]string, n)
+	for i := range out {
+		if e := pm.pick(); e != nil {
+			out[i] = e.url
+		}
+	}
+	return out
+}
+
+func TestPickRoundRobin(t *testing.T) {
+	quarantined := testEntry("b", 0, 0, 0, 0)
+	quarantined.quarantined = true
+	cooling := testEntry("d", 0, 0, 0, 0)
+	cooling.coolUntil = time.Now().Add(time.Hour)
+	pm := testManager(StrategyRoundRobin,
+		testEntry("a", 0, 10, 0, 0), quarantined, testEntry("c", 100, 0, 0, 0), cooling)
+
+	// 不考虑评分，按顺序轮询并跳过隔离和冷却中的代理
+	got := picks(pm, 5)
+	want := []string{"a", "c", "a", "c", "a"}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("挑选顺序 = %v，期望 %v", got, want)
+		}
+	}
+
+	pm = testManager(StrategyRoundRobin, quarantined, cooling)
+	if e := pm.pick(); e != nil {
+		t.Errorf("没有可分配的代理时挑选到 %s", e.url)
+	}
+}
+
+func TestPickLeastFailure(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		entries []*entry
+		want    []string
+	}{
+		{"失败最少", []*entry{
+			testEntry("a", 0, 2, 0, 0),
+			testEntry("b", 0, 1, 0, 0),
+			testEntry("c", 0, 3, 0, 0),
+		}, []string{"b", "b"}},
+		// 一次封禁按 banWeight 次失败计
+		{"封禁加权", []*entry{
+			testEntry("a", 0, 2, 0, 0),
+			testEntry("b", 0, 0, 1, 0),
+		}, []string{"a", "a"}},
+		{"失败相同时选延迟低的", []*entry{
+			testEntry("a", 5, 1, 0, 800*time.Millisecond),
+			testEntry("b", 5, 1, 0, 200*time.Millisecond),
+			testEntry("c", 5, 1, 0, 500*time.Millisecond),
+		}, []string{"b", "b"}},
+		{"完全相同时按轮询顺序", []*entry{
+			testEntry("a", 0, 0, 0, 0),
+			testEntry("b", 0, 0, 0, 0),
+			testEntry("c", 0, 0, 0, 0),
+		}, []string{"a", "b", "c", "a"}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			got := picks(testManager(StrategyLeastFailure, tc.entries...), len(tc.want))
+			for i := range tc.want {
+				if got[i] != tc.want[i] {
+					t.Fatalf("挑选结果 = %v，期望 %v", got, tc.want)
+				}
+			}
+		})
+	}
+
+	// 跳过不可分配的代理，即使它失败最少
+	best := testEntry("best", 0, 0, 0, 0)
+	best.quarantined = true
+	pm := testManager(StrategyLeastFailure, best, testEntry("ok", 0, 5, 0, 0))
+	if e := pm.pick(); e == nil || e.url != "ok" {
+		t.Errorf("挑选到 %v，期望 ok", e)
+	}
+}
+
+func TestPickWeighted(t *testing.T) {
+	good := testEntry("good", 98, 0, 0, 0)    // 成功率 99/100
+	bad := testEntry("bad", 0, 98, 0, 0)      // 成功率 1/100
+	mid := testEntry("mid", 0, 0, 0, 0)       // 没有记录，成功率 0.5
+	cooling := testEntry("cool", 98, 0, 0, 0) // 评分最高但在冷却中
+	cooling.coolUntil = time.Now().Add(time.Hour)
+	pm := testManager(StrategyWeighted, good, bad, mid, cooling)
+
+	const n = 20000
+	counts := map[string]int{}
+	for _, url := range picks(pm, n) {
+		counts[url]++
+	}
+	if counts["cool"] != 0 {
+		t.Errorf("冷却中的代理被挑选 %d 次", counts["cool"])
+	}
+
+	// 挑选比例应接近评分比例：0.99 : 0.01 : 0.5
+	total := 0.99 + 0.01 + 0.5
+	for url, weight := range map[string]float64{"good": 0.99, "bad": 0.01, "mid": 0.5} {
+		want := weight / total
+		got := float64(counts[url]) / n
+		if math.Abs(got-want) > 0.02 {
+			t.Errorf("%s 挑选比例 = %.3f，期望约 %.3f", url, got, want)
+		}
+	}
+
+	pm = testManager(StrategyWeighted, cooling)
+	if e := pm.pick(); e != nil {
+		t.Errorf("没有可分配的代理时挑选到 %s", e.url)
+	}
+}
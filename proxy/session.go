@@ -0,0 +1,58 @@
+package proxy
+
+import (
+	"log"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// SessionProxy 返回绑定到会话的代理函数（实现 colly.ProxyFunc 接口）
+//
+// 同一会话的请求始终使用同一个代理，直到该代理失败（进入冷却、被隔离或移出代理池），
+// 之后按选择策略重新挑选一个代理继续绑定。适用于需要同一 IP 连续翻页的场景，
+// 会话之外的请求仍通过 GetProxy 轮换。
+func (pm *Manager) SessionProxy(key string) func(*http.Request) (*url.URL, error) {
+	return func(r *http.Request) (*url.URL, error) {
+		if pm.useDirect() {
+			return pm.assign(r, Direct)
+		}
+		pm.lock.RLock()
+		bound := pm.sessions[key]
+		e := pm.find(bound)
+		usable := e != nil && e.usable(time.Now())
+		pm.lock.RUnlock()
+		if usable {
+			return pm.assign(r, bound)
+		}
+
+		proxyStr, err := pm.next()
+		if err != nil {
+			if pm.fallbackDirect(err) {
+				return pm.assign(r, Direct)
+			}
+			return nil, err
+		}
+		pm.lock.Lock()
+		pm.sessions[key] = proxyStr
+		pm.lock.Unlock()
+		if bound == "" {
+			log.Printf("[会话] %s 使用代理 %s", key, Redact(proxyStr))
+		} else {
+			log.Printf("[会话] %s 的代理 %s 不可用，切换为 %s", key, Redact(bound), Redact(proxyStr))
+		}
+		return pm.assign(r, proxyStr)
+	}
+}
+
+// EndSession 结束会话，释放绑定的代理；之后同一 key 的请求会重新挑选代理
+// 会话的请求全部完成后调用，否则绑定会一直保留到代理失败为止
+func (pm *Manager) EndSession(key string) {
+	pm.lock.Lock()
+	bound, ok := pm.sessions[key]
+	delete(pm.sessions, key)
+	pm.lock.Unlock()
+	if ok {
+		log.Printf("[会话] %s 结束，释放代理 %s", key, Redact(bound))
+	}
+}
@@ -0,0 +1,59 @@
+package proxy
+
+import (
+	"net/http"
+	"testing"
+)
+
+// sessionPick 通过会话代理函数发送一次请求，返回分配的代理
+func sessionPick(t *testing.T, pm *Manager, key string) string {
+	t.Helper()
+	req, _ := http.NewRequest(http.MethodGet, "http://example.com/", nil)
+	u, err := pm.SessionProxy(key)(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return u.String()
+}
+
+func TestSessionProxySticksUntilEnded(t *testing.T) {
+	pm := testManager(StrategyRoundRobin,
+		testEntry("http://1.1.1.1:8080", 0, 0, 0, 0),
+		testEntry("http://2.2.2.2:8080", 0, 0, 0, 0))
+
+	first := sessionPick(t, pm, "apple")
+	for range 3 {
+		if got := sessionPick(t, pm, "apple"); got != first {
+			t.Fatalf("会话内切换了代理: %s -> %s", first, got)
+		}
+	}
+	other := sessionPick(t, pm, "samsung")
+	if other == first {
+		t.Errorf("轮询策略下两个会话绑定了同一个代理 %s", other)
+	}
+	if n := pm.Stats().Sessions; n != 2 {
+		t.Errorf("会话数 = %d，期望 2", n)
+	}
+
+	// 结束后释放绑定，再次使用同一 key 时按策略重新挑选
+	pm.EndSession("apple")
+	pm.EndSession("unknown")
+	if n := pm.Stats().Sessions; n != 1 {
+		t.Errorf("结束后会话数 = %d，期望 1", n)
+	}
+	if got := sessionPick(t, pm, "apple"); got != first {
+		t.Errorf("重新挑选的代理 = %s，期望轮询到 %s", got, first)
+	}
+}
+
+func TestSessionProxySwitchesOnFailure(t *testing.T) {
+	pm := testManager(StrategyRoundRobin,
+		testEntry("http://1.1.1.1:8080", 0, 0, 0, 0),
+		testEntry("http://2.2.2.2:8080", 0, 0, 0, 0))
+
+	first := sessionPick(t, pm, "apple")
+	pm.ReportFailure(first, FailureBan)
+	if got := sessionPick(t, pm, "apple"); got == first {
+		t.Errorf("绑定的代理冷却后仍被使用: %s", got)
+	}
+}
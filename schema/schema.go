@@ -0,0 +1,150 @@
+// Package schema 根据 Go 类型生成 JSON Schema，并校验 JSON 数据是否符合 Schema
+//
+// 只覆盖抓取记录用到的类型（字符串、数值、布尔、结构体、map、切片、指针），
+// 生成的 Schema 与 encoding/json 的序列化结果一致：
+// 没有 omitempty 的 map / 切片 / 指针字段可能为 null，带 omitempty 的字段不在 required 中。
+package schema
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/yangbin1322/go-gsmarena/crawler"
+)
+
+// phone.schema.json 为发布的 Schema 文件，修改 Phone 后重新生成
+//go:generate go run .. schema -out phone.schema.json
+
+// Draft 生成的 Schema 遵循的规范版本
+const Draft = "https://json-schema.org/draft/2020-12/schema"
+
+// Schema JSON Schema 的子集
+type Schema struct {
+	Schema      string `json:"$schema,omitempty"`
+	ID          string `json:"$id,omitempty"`
+	Title       string `json:"title,omitempty"`
+	Description string `json:"description,omitempty"`
+
+	// Type 类型名称，可为 null 的字段为 ["类型", "null"]
+	Type  any `json:"type,omitempty"`
+	Const any `json:"const,omitempty"`
+
+	Properties map[string]*Schema `json:"properties,omitempty"`
+	Required   []string           `json:"required,omitempty"`
+	// AdditionalProperties 结构体为 false（不允许未知字段），map 为值的 Schema
+	AdditionalProperties any `json:"additionalProperties,omitempty"`
+
+	Items *Schema `json:"items,omitempty"`
+}
+
+// UnmarshalJSON 将 additionalProperties 解析为 bool 或 *Schema，读取 Schema 文件后可直接用于校验
+func (s *Schema) UnmarshalJSON(data []byte) error {
+	type plain Schema
+	raw := struct {
+		*plain
+		AdditionalProperties json.RawMessage `json:"additionalProperties,omitempty"`
+	}{plain: (*plain)(s)}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+
+	s.AdditionalProperties = nil
+	if len(raw.AdditionalProperties) == 0 {
+		return nil
+	}
+	var allowed bool
+	if err := json.Unmarshal(raw.AdditionalProperties, &allowed); err == nil {
+		s.AdditionalProperties = allowed
+		return nil
+	}
+	extra := &Schema{}
+	if err := json.Unmarshal(raw.AdditionalProperties, extra); err != nil {
+		return fmt.Errorf("additionalProperties 格式错误: %w", err)
+	}
+	s.AdditionalProperties = extra
+	return nil
+}
+
+// Phone 返回 crawler.Phone 记录的 JSON Schema
+func Phone() *Schema {
+	s := Generate(reflect.TypeOf(crawler.Phone{}))
+	s.Schema = Draft
+	s.ID = "https://github.com/yangbin1322/go-gsmarena/schema/phone.schema.json"
+	s.Title = "Phone"
+	s.Description = "GSMArena 手机详情记录（results.jsonl 的每一行）"
+	s.Properties["schema_version"].Const = crawler.SchemaVersion
+	return s
+}
+
+// Generate 通过反射生成类型 t 的 Schema
+func Generate(t reflect.Type) *Schema {
+	return generate(t, false)
+}
+
+// generate nullable 表示该值在 JSON 中可能为 null
+func generate(t reflect.Type, nullable bool) *Schema {
+	var s *Schema
+	switch t.Kind() {
+	case reflect.Pointer:
+		return generate(t.Elem(), nullable)
+	case reflect.String:
+		s = &Schema{Type: "string"}
+	case reflect.Bool:
+		s = &Schema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		s = &Schema{Type: "integer"}
+	case reflect.Float32, reflect.Float64:
+		s = &Schema{Type: "number"}
+	case reflect.Map:
+		s = &Schema{Type: "object", AdditionalProperties: generate(t.Elem(), false)}
+	case reflect.Slice, reflect.Array:
+		s = &Schema{Type: "array", Items: generate(t.Elem(), false)}
+	case reflect.Struct:
+		s = generateStruct(t)
+	default:
+		// interface 等无法确定类型的值不做限制
+		return &Schema{}
+	}
+	if nullable {
+		s.Type = []string{s.Type.(string), "null"}
+	}
+	return s
+}
+
+// generateStruct 按 json 标签生成结构体的 Schema
+func generateStruct(t reflect.Type) *Schema {
+	s := &Schema{
+		Type:                 "object",
+		Properties:           make(map[string]*Schema),
+		AdditionalProperties: false,
+	}
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		name, opts, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		omitempty := strings.Contains(opts, "omitempty")
+
+		// 没有 omitempty 的 nil map / 切片 / 指针会序列化为 null
+		kind := field.Type.Kind()
+		nullable := !omitempty && (kind == reflect.Map || kind == reflect.Slice || kind == reflect.Pointer)
+
+		s.Properties[name] = generate(field.Type, nullable)
+		if !omitempty {
+			s.Required = append(s.Required, name)
+		}
+	}
+	sort.Strings(s.Required)
+	return s
+}
@@ -0,0 +1,176 @@
+package schema
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+)
+
+// Violation 一处不符合 Schema 的数据
+type Violation struct {
+	Path    string // JSON 路径，如 $.sections[0].name
+	Message string
+}
+
+func (v Violation) String() string {
+	return v.Path + ": " + v.Message
+}
+
+// ValidateJSON 校验一条 JSON 数据，数据本身不是合法 JSON 时返回错误
+func ValidateJSON(s *Schema, data []byte) ([]Violation, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var value any
+	if err := decoder.Decode(&value); err != nil {
+		return nil, fmt.Errorf("JSON 解析失败: %w", err)
+	}
+	return Validate(s, value), nil
+}
+
+// Validate 校验 json.Decoder（UseNumber）解析出的值
+func Validate(s *Schema, value any) []Violation {
+	var violations []Violation
+	validate(s, value, "$", &violations)
+	return violations
+}
+
+func validate(s *Schema, value any, path string, violations *[]Violation) {
+	report := func(format string, args ...any) {
+		*violations = append(*violations, Violation{Path: path, Message: fmt.Sprintf(format, args...)})
+	}
+
+	if s.Type != nil && !matchType(s.Type, value) {
+		report("类型应为 %s，实际为 %s", typeNames(s.Type), typeOf(value))
+		return
+	}
+	if s.Const != nil && !constEqual(s.Const, value) {
+		report("值应为 %v，实际为 %v", s.Const, value)
+	}
+
+	switch v := value.(type) {
+	case map[string]any:
+		for _, name := range s.Required {
+			if _, ok := v[name]; !ok {
+				report("缺少必填字段 %s", name)
+			}
+		}
+
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			child := path + "." + key
+			if prop, ok := s.Properties[key]; ok {
+				validate(prop, v[key], child, violations)
+				continue
+			}
+			switch extra := s.AdditionalProperties.(type) {
+			case bool:
+				if !extra {
+					*violations = append(*violations, Violation{Path: child, Message: "未定义的字段"})
+				}
+			case *Schema:
+				validate(extra, v[key], child, violations)
+			}
+		}
+	case []any:
+		if s.Items != nil {
+			for i, item := range v {
+				validate(s.Items, item, fmt.Sprintf("%s[%d]", path, i), violations)
+			}
+		}
+	}
+}
+
+// matchType 检查值是否符合 type（字符串或字符串列表）
+func matchType(t any, value any) bool {
+	switch t := t.(type) {
+	case string:
+		return matchOne(t, value)
+	case []string:
+		for _, name := range t {
+			if matchOne(name, value) {
+				return true
+			}
+		}
+		return false
+	case []any:
+		for _, name := range t {
+			if name, ok := name.(string); ok && matchOne(name, value) {
+				return true
+			}
+		}
+		return false
+	}
+	return true
+}
+
+func matchOne(name string, value any) bool {
+	switch name {
+	case "integer":
+		n, ok := value.(json.Number)
+		return ok && isInteger(n)
+	case "number":
+		_, ok := value.(json.Number)
+		return ok
+	default:
+		return typeOf(value) == name
+	}
+}
+
+// isInteger 小数部分为零的数值都是整数（JSON Schema 中 1.0 与 1 等价）
+func isInteger(n json.Number) bool {
+	if _, err := n.Int64(); err == nil {
+		return true
+	}
+	f, err := n.Float64()
+	return err == nil && f == math.Trunc(f)
+}
+
+// constEqual 按 JSON 表示比较 const，字符串 "2" 与数值 2 不相等
+func constEqual(want, value any) bool {
+	a, errA := json.Marshal(want)
+	b, errB := json.Marshal(value)
+	return errA == nil && errB == nil && bytes.Equal(a, b)
+}
+
+// typeOf 返回值的 JSON 类型名称
+func typeOf(value any) string {
+	switch v := value.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case string:
+		return "string"
+	case json.Number:
+		if isInteger(v) {
+			return "integer"
+		}
+		return "number"
+	case []any:
+		return "array"
+	case map[string]any:
+		return "object"
+	}
+	return fmt.Sprintf("%T", value)
+}
+
+func typeNames(t any) string {
+	switch t := t.(type) {
+	case []string:
+		return strings.Join(t, " 或 ")
+	case []any:
+		names := make([]string, len(t))
+		for i, name := range t {
+			names[i] = fmt.Sprint(name)
+		}
+		return strings.Join(names, " 或 ")
+	}
+	return fmt.Sprint(t)
+}
@@ -0,0 +1,198 @@
+package schema
+
+import (
+	"encoding/json"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/yangbin1322/go-gsmarena/crawler"
+)
+
+// violations 校验 JSON 并返回问题列表（"路径: 信息"）
+func violations(t *testing.T, s *Schema, data string) []string {
+	t.Helper()
+	vs, err := ValidateJSON(s, []byte(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	out := make([]string, len(vs))
+	for i, v := range vs {
+		out[i] = v.String()
+	}
+	return out
+}
+
+func TestValidateTypes(t *testing.T) {
+	for _, tc := range []struct {
+		name   string
+		schema *Schema
+		data   string
+		ok     bool
+	}{
+		{"字符串", &Schema{Type: "string"}, `"a"`, true},
+		{"字符串不是数值", &Schema{Type: "string"}, `1`, false},
+		{"整数", &Schema{Type: "integer"}, `42`, true},
+		{"负整数", &Schema{Type: "integer"}, `-7`, true},
+		{"小数部分为零的整数", &Schema{Type: "integer"}, `1.0`, true},
+		{"指数形式的整数", &Schema{Type: "integer"}, `1e3`, true},
+		{"超出 int64 的整数", &Schema{Type: "integer"}, `18446744073709551616`, true},
+		{"小数不是整数", &Schema{Type: "integer"}, `1.5`, false},
+		{"字符串数字不是整数", &Schema{Type: "integer"}, `"1"`, false},
+		{"整数也是数值", &Schema{Type: "number"}, `3`, true},
+		{"小数", &Schema{Type: "number"}, `3.14`, true},
+		{"布尔值不是数值", &Schema{Type: "number"}, `true`, false},
+		{"布尔值", &Schema{Type: "boolean"}, `false`, true},
+		{"null", &Schema{Type: "null"}, `null`, true},
+		{"数组", &Schema{Type: "array"}, `[]`, true},
+		{"对象不是数组", &Schema{Type: "array"}, `{}`, false},
+		{"对象", &Schema{Type: "object"}, `{}`, true},
+		// 生成的 Schema 中可为 null 的字段为 []string，从文件读取时为 []any
+		{"联合类型匹配第一个", &Schema{Type: []string{"array", "null"}}, `[]`, true},
+		{"联合类型匹配第二个", &Schema{Type: []string{"array", "null"}}, `null`, true},
+		{"联合类型都不匹配", &Schema{Type: []string{"array", "null"}}, `"x"`, false},
+		{"读取的联合类型", &Schema{Type: []any{"integer", "null"}}, `null`, true},
+		{"读取的联合类型不匹配", &Schema{Type: []any{"integer", "null"}}, `2.5`, false},
+		{"没有类型限制", &Schema{}, `{"a": [1, "b"]}`, true},
+		{"const 相同", &Schema{Type: "integer", Const: 2}, `2`, true},
+		{"const 不同", &Schema{Type: "integer", Const: 2}, `1`, false},
+		{"const 类型不同", &Schema{Const: 2}, `"2"`, false},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			got := violations(t, tc.schema, tc.data)
+			if ok := len(got) == 0; ok != tc.ok {
+				t.Errorf("校验 %s: 问题 = %v，期望通过 = %v", tc.data, got, tc.ok)
+			}
+		})
+	}
+}
+
+func TestValidateObject(t *testing.T) {
+	item := &Schema{
+		Type: "object",
+		Properties: map[string]*Schema{
+			"name":  {Type: "string"},
+			"count": {Type: "integer"},
+		},
+		Required:             []string{"count", "name"},
+		AdditionalProperties: false,
+	}
+	s := &Schema{
+		Type: "object",
+		Properties: map[string]*Schema{
+			"items": {Type: "array", Items: item},
+			"tags":  {Type: "object", AdditionalProperties: &Schema{Type: "string"}},
+			"extra": {Type: "object", AdditionalProperties: true},
+		},
+		Required: []string{"items"},
+	}
+
+	for _, tc := range []struct {
+		name string
+		data string
+		want []string
+	}{
+		{"合法", `{"items": [{"name": "a", "count": 1}], "tags": {"k": "v"}, "extra": {"x": 1}}`, []string{}},
+		{"缺少必填字段", `{"items": [{"name": "a"}, {}]}`, []string{
+			"$.items[0]: 缺少必填字段 count",
+			"$.items[1]: 缺少必填字段 count",
+			"$.items[1]: 缺少必填字段 name",
+		}},
+		// 值为 null 也算存在
+		{"必填字段为 null", `{"items": [{"name": null, "count": 1}]}`, []string{"$.items[0].name: 类型应为 string，实际为 null"}},
+		{"additionalProperties 为 false", `{"items": [{"name": "a", "count": 1, "size": 2, "color": "red"}]}`, []string{
+			"$.items[0].color: 未定义的字段",
+			"$.items[0].size: 未定义的字段",
+		}},
+		{"additionalProperties 为 Schema", `{"items": [], "tags": {"a": "x", "b": 2, "c": null}}`, []string{
+			"$.tags.b: 类型应为 string，实际为 integer",
+			"$.tags.c: 类型应为 string，实际为 null",
+		}},
+		// 未设置 additionalProperties 或为 true 时不限制
+		{"additionalProperties 未设置或为 true", `{"items": [], "other": 1, "extra": {"x": [1]}}`, []string{}},
+		{"类型错误时不检查子字段", `{"items": {"name": 1}}`, []string{"$.items: 类型应为 array，实际为 object"}},
+		{"整数字段为小数", `{"items": [{"name": "a", "count": 1.5}]}`, []string{"$.items[0].count: 类型应为 integer，实际为 number"}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			got := violations(t, s, tc.data)
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("问题 = %q\n期望 %q", got, tc.want)
+			}
+		})
+	}
+
+	if _, err := ValidateJSON(s, []byte(`{"items": [`)); err == nil {
+		t.Error("不是合法 JSON 时应返回错误")
+	}
+}
+
+func TestValidatePhone(t *testing.T) {
+	phone := crawler.Phone{
+		SchemaVersion: crawler.SchemaVersion,
+		ModelName:     "Phone 1",
+		URL:           "https://www.gsmarena.com/test_phone-1.php",
+		Specs:         map[string]string{"Size": "6.1 inches"},
+		Sections:      []crawler.SpecSection{{Name: "Display", Fields: []crawler.SpecField{{Name: "Size", Value: "6.1 inches"}}}},
+	}
+	data, err := json.Marshal(phone)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := violations(t, Phone(), string(data)); len(got) != 0 {
+		t.Errorf("序列化的 Phone 未通过校验: %v", got)
+	}
+
+	// nil map 序列化为 null，与生成的 Schema 一致
+	data, _ = json.Marshal(crawler.Phone{SchemaVersion: crawler.SchemaVersion})
+	if got := violations(t, Phone(), string(data)); len(got) != 0 {
+		t.Errorf("空 Phone 未通过校验: %v", got)
+	}
+
+	got := violations(t, Phone(), `{"schema_version": 99, "model_name": 3, "specs": {"Size": 6.1}, "unknown": true}`)
+	for _, want := range []string{
+		"$.schema_version: 值应为",
+		"$.model_name: 类型应为 string",
+		"$.specs.Size: 类型应为 string",
+		"$.unknown: 未定义的字段",
+		"$: 缺少必填字段 url",
+	} {
+		found := false
+		for _, v := range got {
+			found = found || strings.HasPrefix(v, want)
+		}
+		if !found {
+			t.Errorf("问题中缺少 %q: %q", want, got)
+		}
+	}
+}
+
+func TestPublishedSchema(t *testing.T) {
+	data, err := os.ReadFile("phone.schema.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	generated, err := json.MarshalIndent(Phone(), "", "  ")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != string(generated)+"\n" {
+		t.Error("phone.schema.json 与 Phone 不一致，请运行 go generate ./schema")
+	}
+
+	// 从文件读取的 Schema 与生成的 Schema 校验结果相同
+	var loaded Schema
+	if err := json.Unmarshal(data, &loaded); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := loaded.AdditionalProperties.(bool); !ok {
+		t.Errorf("additionalProperties = %#v，期望 bool", loaded.AdditionalProperties)
+	}
+	if _, ok := loaded.Properties["specs"].AdditionalProperties.(*Schema); !ok {
+		t.Errorf("specs.additionalProperties = %#v，期望 *Schema", loaded.Properties["specs"].AdditionalProperties)
+	}
+	record := `{"schema_version": 99, "model_name": "x", "specs": {"Size": 6.1}, "unknown": true}`
+	if a, b := violations(t, &loaded, record), violations(t, Phone(), record); !reflect.DeepEqual(a, b) {
+		t.Errorf("读取的 Schema 校验结果 = %q\n生成的 Schema = %q", a, b)
+	}
+}
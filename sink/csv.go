@@ -0,0 +1,118 @@
+package sink
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/yangbin1322/go-gsmarena/crawler"
+)
+
+// csvHeader CSV 输出的列（规格参数整体序列化为 JSON 放在 specs 列）
+var csvHeader = []string{"model_name", "brand", "release_date", "url", "crawled_at", "specs"}
+
+// CSV 将手机数据追加写入 CSV 文件
+// 流式写入时无法预知所有规格字段，规格参数以 JSON 形式保存在单独一列
+type CSV struct {
+	path   string
+	file   *os.File
+	writer *csv.Writer
+	mu     sync.Mutex // 文件写入锁
+}
+
+// NewCSV 以追加模式打开（或创建）CSV 文件，新文件会先写入表头
+func NewCSV(path string) (*CSV, error) {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("打开 CSV 文件失败: %w", err)
+	}
+
+	s := &CSV{path: path, file: file, writer: csv.NewWriter(file)}
+
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return nil, fmt.Errorf("读取 CSV 文件信息失败: %w", err)
+	}
+	if info.Size() == 0 {
+		if err := s.writer.Write(csvHeader); err != nil {
+			file.Close()
+			return nil, fmt.Errorf("写入 CSV 表头失败: %w", err)
+		}
+		s.writer.Flush()
+	}
+	return s, nil
+}
+
+// Write 写入一条手机数据
+func (s *CSV) Write(phone crawler.Phone) error {
+	specs, err := json.Marshal(phone.Specs)
+	if err != nil {
+		return fmt.Errorf("JSON 序列化失败: %w", err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.writer.Write([]string{
+		phone.ModelName,
+		phone.Brand,
+		phone.ReleaseDate,
+		phone.URL,
+		phone.CrawledAt,
+		string(specs),
+	})
+}
+
+// Flush 将缓冲区写入文件
+func (s *CSV) Flush() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.writer.Flush()
+	return s.writer.Error()
+}
+
+// Existing 扫描 CSV 文件，返回 urls 中已写入文件的 URL
+func (s *CSV) Existing(urls map[string]bool) (map[string]bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	found := make(map[string]bool)
+	urlColumn := -1
+	err := scanLines(s.path, func(line []byte) {
+		record, err := csv.NewReader(strings.NewReader(string(line))).Read()
+		if err != nil {
+			return
+		}
+		if urlColumn < 0 {
+			// 第一行为表头
+			for i, name := range record {
+				if name == "url" {
+					urlColumn = i
+				}
+			}
+			return
+		}
+		if urlColumn < len(record) && urls[record[urlColumn]] {
+			found[record[urlColumn]] = true
+		}
+	})
+	return found, err
+}
+
+// Close 刷新并关闭 CSV 文件
+func (s *CSV) Close() error {
+	if err := s.Flush(); err != nil {
+		s.file.Close()
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.file.Sync(); err != nil {
+		s.file.Close()
+		return fmt.Errorf("同步 CSV 文件失败: %w", err)
+	}
+	return s.file.Close()
+}
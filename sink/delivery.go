@@ -0,0 +1,251 @@
+package sink
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/yangbin1322/go-gsmarena/crawler"
+	"github.com/yangbin1322/go-gsmarena/storage"
+)
+
+// 推送类输出（Webhook、消息队列）的默认配置
+const (
+	DefaultBatchSize     = 50
+	DefaultFlushInterval = 2 * time.Second
+	DefaultMinBackoff    = time.Second
+	DefaultMaxBackoff    = time.Minute
+	// DefaultDeliveryDrain 关闭时尽量投递剩余消息的最长时间，未投递的消息留在发件箱中下次继续
+	DefaultDeliveryDrain = 10 * time.Second
+)
+
+// 推送事件类型
+const (
+	EventCreated = "created" // 首次发现的设备
+	EventUpdated = "updated" // 内容发生变化的设备
+)
+
+// Event 推送给下游服务的事件
+type Event struct {
+	Type     string        `json:"type"`
+	DeviceID string        `json:"device_id"`
+	Phone    crawler.Phone `json:"phone"`
+}
+
+// permanentError 对端永久拒绝（如 4xx），重试无意义，消息移入死信队列
+type permanentError struct {
+	err error
+}
+
+func (e permanentError) Error() string { return e.err.Error() }
+func (e permanentError) Unwrap() error { return e.err }
+
+// deliveryOptions 推送配置
+type deliveryOptions struct {
+	BatchSize     int
+	FlushInterval time.Duration
+	MinBackoff    time.Duration
+	MaxBackoff    time.Duration
+	DrainTimeout  time.Duration
+}
+
+// withDefaults 填充默认值
+func (o deliveryOptions) withDefaults() deliveryOptions {
+	if o.BatchSize <= 0 {
+		o.BatchSize = DefaultBatchSize
+	}
+	if o.FlushInterval <= 0 {
+		o.FlushInterval = DefaultFlushInterval
+	}
+	if o.MinBackoff <= 0 {
+		o.MinBackoff = DefaultMinBackoff
+	}
+	if o.MaxBackoff <= 0 {
+		o.MaxBackoff = DefaultMaxBackoff
+	}
+	if o.DrainTimeout <= 0 {
+		o.DrainTimeout = DefaultDeliveryDrain
+	}
+	return o
+}
+
+// delivery 发件箱 + 后台投递循环，供 Webhook、消息队列等推送类输出复用
+//
+// Write 只把新增或变化的记录写入磁盘发件箱（写入即视为输出成功），
+// 后台循环按批次投递，失败时指数退避重试；对端确认后才从发件箱删除。
+type delivery struct {
+	name   string
+	outbox *storage.Outbox
+	opts   deliveryOptions
+	// send 投递一批消息，返回 permanentError 时该批消息移入死信队列
+	send func(ctx context.Context, items []storage.OutboxItem) error
+
+	notify chan struct{}
+	stop   chan struct{}
+	done   chan struct{}
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	mu        sync.Mutex // 保护统计
+	delivered int
+	dead      int
+}
+
+// newDelivery 打开发件箱并启动后台投递
+func newDelivery(name, outboxPath string, opts deliveryOptions, send func(ctx context.Context, items []storage.OutboxItem) error) (*delivery, error) {
+	outbox, err := storage.NewOutbox(outboxPath)
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	d := &delivery{
+		name:   name,
+		outbox: outbox,
+		opts:   opts.withDefaults(),
+		send:   send,
+		notify: make(chan struct{}, 1),
+		stop:   make(chan struct{}),
+		done:   make(chan struct{}),
+		ctx:    ctx,
+		cancel: cancel,
+	}
+	if n := outbox.Len(); n > 0 {
+		log.Printf("[%s] 发件箱中有 %d 条上次未投递的消息", name, n)
+	}
+	go d.loop()
+	return d, nil
+}
+
+// enqueue 记录内容有变化时写入发件箱
+func (d *delivery) enqueue(phone crawler.Phone) error {
+	key := crawler.DeviceID(phone.URL)
+	if key == "" {
+		key = phone.URL
+	}
+
+	// 抓取时间和格式版本不算内容变化
+	content := phone
+	content.CrawledAt = ""
+	content.SchemaVersion = 0
+	data, err := json.Marshal(content)
+	if err != nil {
+		return fmt.Errorf("JSON 序列化失败: %w", err)
+	}
+	hash := sha256.Sum256(data)
+
+	_, err = d.outbox.Enqueue(key, hash[:], func(existed bool) ([]byte, error) {
+		event := Event{Type: EventCreated, DeviceID: key, Phone: phone}
+		if existed {
+			event.Type = EventUpdated
+		}
+		return json.Marshal(event)
+	})
+	return err
+}
+
+// eventType 读取事件 JSON 中的类型
+func eventType(data []byte) string {
+	var event struct {
+		Type string `json:"type"`
+	}
+	json.Unmarshal(data, &event)
+	return event.Type
+}
+
+// wake 通知后台循环检查发件箱
+func (d *delivery) wake() {
+	select {
+	case d.notify <- struct{}{}:
+	default:
+	}
+}
+
+// loop 后台投递循环：积累满一批或每隔 FlushInterval 投递一次
+func (d *delivery) loop() {
+	defer close(d.done)
+	ticker := time.NewTicker(d.opts.FlushInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-d.stop:
+			return
+		case <-ticker.C:
+		case <-d.notify:
+			if d.outbox.Len() < d.opts.BatchSize {
+				continue
+			}
+		}
+		d.deliverAll(true)
+	}
+}
+
+// deliverAll 投递发件箱中的所有消息
+// retry 为 true 时失败后退避重试（收到停止信号时返回），否则遇到失败立即返回
+func (d *delivery) deliverAll(retry bool) error {
+	backoff := d.opts.MinBackoff
+	for {
+		items, err := d.outbox.Peek(d.opts.BatchSize)
+		if err != nil || len(items) == 0 {
+			return err
+		}
+
+		err = d.send(d.ctx, items)
+		var permanent permanentError
+		switch {
+		case err == nil:
+			if err := d.outbox.Ack(items...); err != nil {
+				return err
+			}
+			d.mu.Lock()
+			d.delivered += len(items)
+			d.mu.Unlock()
+			backoff = d.opts.MinBackoff
+			continue
+		case errors.As(err, &permanent):
+			log.Printf("[%s] 对端拒绝 %d 条消息，移入死信队列: %v", d.name, len(items), err)
+			if err := d.outbox.DeadLetter(items...); err != nil {
+				return err
+			}
+			d.mu.Lock()
+			d.dead += len(items)
+			d.mu.Unlock()
+			continue
+		}
+
+		if !retry {
+			return err
+		}
+		log.Printf("[%s] 投递失败，%v 后重试: %v", d.name, backoff, err)
+		select {
+		case <-d.stop:
+			return err
+		case <-time.After(backoff):
+		}
+		backoff = min(backoff*2, d.opts.MaxBackoff)
+	}
+}
+
+// close 停止后台循环，在 DrainTimeout 内尽量投递剩余消息后关闭发件箱
+func (d *delivery) close() error {
+	close(d.stop)
+	<-d.done
+
+	timer := time.AfterFunc(d.opts.DrainTimeout, d.cancel)
+	err := d.deliverAll(false)
+	timer.Stop()
+	d.cancel()
+
+	d.mu.Lock()
+	log.Printf("[%s] 已投递 %d 条，死信 %d 条，发件箱剩余 %d 条", d.name, d.delivered, d.dead, d.outbox.Len())
+	d.mu.Unlock()
+	if err != nil {
+		log.Printf("[%s] 剩余消息将在下次启动时继续投递: %v", d.name, err)
+	}
+	return d.outbox.Close()
+}
@@ -0,0 +1,40 @@
+package sink
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+)
+
+// scanLines 逐行读取输出文件（每行一条记录），fn 收到的行包含结尾的换行符
+// 文件末尾不以换行结束的半行（写入时崩溃）会被截断
+func scanLines(path string, fn func(line []byte)) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("读取输出文件失败: %w", err)
+	}
+	defer f.Close()
+
+	reader := bufio.NewReader(f)
+	var offset int64
+	for {
+		line, err := reader.ReadBytes('\n')
+		if errors.Is(err, io.EOF) {
+			if len(line) > 0 {
+				log.Printf("[恢复] 截断输出文件末尾的不完整行（%d 字节）: %s", len(line), path)
+				if err := os.Truncate(path, offset); err != nil {
+					return fmt.Errorf("截断输出文件失败: %w", err)
+				}
+			}
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("读取输出文件失败: %w", err)
+		}
+		offset += int64(len(line))
+		fn(line)
+	}
+}
@@ -0,0 +1,82 @@
+package sink
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+
+	"github.com/yangbin1322/go-gsmarena/crawler"
+)
+
+// JSONL 将手机数据以 JSONL 格式（每行一个 JSON 对象）追加写入文件
+type JSONL struct {
+	path string
+	file *os.File
+	mu   sync.Mutex // 文件写入锁
+}
+
+// NewJSONL 以追加模式打开（或创建）输出文件
+func NewJSONL(path string) (*JSONL, error) {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("打开输出文件失败: %w", err)
+	}
+	return &JSONL{path: path, file: file}, nil
+}
+
+// Write 写入一条手机数据
+func (s *JSONL) Write(phone crawler.Phone) error {
+	// 序列化为 JSON
+	data, err := json.Marshal(phone)
+	if err != nil {
+		return fmt.Errorf("JSON 序列化失败: %w", err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// 写入文件（每行一个 JSON 对象）
+	if _, err := s.file.Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("写入文件失败: %w", err)
+	}
+	return nil
+}
+
+// Flush 每条记录直接写入文件，无需额外刷新
+func (s *JSONL) Flush() error {
+	return nil
+}
+
+// Existing 扫描输出文件，返回 urls 中已写入文件的 URL
+func (s *JSONL) Existing(urls map[string]bool) (map[string]bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	found := make(map[string]bool)
+	err := scanLines(s.path, func(line []byte) {
+		var record struct {
+			URL string `json:"url"`
+		}
+		if json.Unmarshal(line, &record) == nil && urls[record.URL] {
+			found[record.URL] = true
+		}
+	})
+	return found, err
+}
+
+// Path 返回输出文件路径
+func (s *JSONL) Path() string {
+	return s.path
+}
+
+// Close 将缓冲数据落盘并关闭输出文件
+func (s *JSONL) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.file.Sync(); err != nil {
+		s.file.Close()
+		return fmt.Errorf("同步输出文件失败: %w", err)
+	}
+	return s.file.Close()
+}
@@ -0,0 +1,180 @@
+package sink
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+	"github.com/yangbin1322/go-gsmarena/crawler"
+	"github.com/yangbin1322/go-gsmarena/storage"
+)
+
+// 消息头
+const (
+	// NATSEventHeader 事件类型（created / updated）
+	NATSEventHeader = "Gsmarena-Event"
+	// NATSDeviceHeader 设备 ID
+	NATSDeviceHeader = "Gsmarena-Device"
+)
+
+// DefaultNATSSubject 默认主题前缀，消息主题为 <前缀>.<设备 ID>
+const DefaultNATSSubject = "gsmarena.phones"
+
+// NATSOptions NATS JetStream 输出配置
+type NATSOptions struct {
+	URL string // 服务器地址，如 nats://127.0.0.1:4222
+
+	// Conn 已建立的连接（如进程内的嵌入式服务器），指定时忽略 URL，Close 时不关闭该连接
+	Conn *nats.Conn
+
+	// Subject 主题前缀，默认 DefaultNATSSubject
+	Subject string
+	// Stream 指定时在启动时创建（或更新）该 Stream，订阅 <Subject>.>；为空时要求 Stream 已存在
+	Stream string
+
+	// Outbox 发件箱（BoltDB）文件路径，默认 nats-outbox.db
+	Outbox string
+
+	BatchSize     int           // 每批发布的消息数，默认 DefaultBatchSize
+	FlushInterval time.Duration // 不足一批时的最长等待时间，默认 DefaultFlushInterval
+	MinBackoff    time.Duration // 首次重试等待时间，默认 DefaultMinBackoff
+	MaxBackoff    time.Duration // 最长重试等待时间，默认 DefaultMaxBackoff
+}
+
+// NATS 将新增或变化的手机数据以事件形式发布到 NATS JetStream
+//
+// 消息主题为 <Subject>.<设备 ID>，消息体为 Event JSON。
+// 消息先写入本地发件箱，收到 JetStream 的发布确认（PubAck）后才从发件箱删除（至少一次）；
+// 重试时使用相同的 Nats-Msg-Id，Stream 的去重窗口内不会产生重复消息。
+type NATS struct {
+	conn     *nats.Conn
+	ownConn  bool
+	js       jetstream.JetStream
+	subject  string
+	delivery *delivery
+}
+
+// NewNATS 连接服务器并启动后台发布
+func NewNATS(opts NATSOptions) (*NATS, error) {
+	if opts.Subject == "" {
+		opts.Subject = DefaultNATSSubject
+	}
+	if opts.Outbox == "" {
+		opts.Outbox = "nats-outbox.db"
+	}
+
+	s := &NATS{conn: opts.Conn, subject: opts.Subject}
+	if s.conn == nil {
+		conn, err := nats.Connect(opts.URL,
+			nats.Name("go-gsmarena"),
+			nats.MaxReconnects(-1),
+		)
+		if err != nil {
+			return nil, fmt.Errorf("连接 NATS 失败: %w", err)
+		}
+		s.conn, s.ownConn = conn, true
+	}
+
+	js, err := jetstream.New(s.conn)
+	if err != nil {
+		s.closeConn()
+		return nil, fmt.Errorf("初始化 JetStream 失败: %w", err)
+	}
+	s.js = js
+
+	if opts.Stream != "" {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		_, err := js.CreateOrUpdateStream(ctx, jetstream.StreamConfig{
+			Name:     opts.Stream,
+			Subjects: []string{opts.Subject + ".>"},
+		})
+		cancel()
+		if err != nil {
+			s.closeConn()
+			return nil, fmt.Errorf("创建 Stream %s 失败: %w", opts.Stream, err)
+		}
+		log.Printf("[NATS] Stream %s 已就绪（主题 %s.>）", opts.Stream, opts.Subject)
+	}
+
+	d, err := newDelivery("NATS", opts.Outbox, deliveryOptions{
+		BatchSize:     opts.BatchSize,
+		FlushInterval: opts.FlushInterval,
+		MinBackoff:    opts.MinBackoff,
+		MaxBackoff:    opts.MaxBackoff,
+	}, s.send)
+	if err != nil {
+		s.closeConn()
+		return nil, err
+	}
+	s.delivery = d
+	return s, nil
+}
+
+// Write 记录内容有变化时写入发件箱，由后台发布
+func (s *NATS) Write(phone crawler.Phone) error {
+	return s.delivery.enqueue(phone)
+}
+
+// Flush 满一批时通知后台立即发布
+func (s *NATS) Flush() error {
+	s.delivery.wake()
+	return nil
+}
+
+// Close 停止后台发布，尽量发布剩余消息后关闭发件箱和连接
+func (s *NATS) Close() error {
+	err := s.delivery.close()
+	s.closeConn()
+	return err
+}
+
+// closeConn 关闭自行建立的连接
+func (s *NATS) closeConn() {
+	if s.ownConn {
+		s.conn.Close()
+	}
+}
+
+// send 异步发布一批消息，全部收到 PubAck 才算成功
+// 部分失败时整批重试，已确认的消息依靠 Nats-Msg-Id 去重
+func (s *NATS) send(ctx context.Context, items []storage.OutboxItem) error {
+	futures := make([]jetstream.PubAckFuture, 0, len(items))
+	for _, item := range items {
+		msg := nats.NewMsg(s.subject + "." + subjectToken(item.Key))
+		msg.Data = item.Data
+		msg.Header.Set(NATSDeviceHeader, item.Key)
+		msg.Header.Set(NATSEventHeader, eventType(item.Data))
+
+		future, err := s.js.PublishMsgAsync(msg, jetstream.WithMsgID(fmt.Sprintf("%s-%d", item.Key, item.Seq)))
+		if err != nil {
+			return fmt.Errorf("发布消息失败: %w", err)
+		}
+		futures = append(futures, future)
+	}
+
+	for _, future := range futures {
+		select {
+		case <-future.Ok():
+		case err := <-future.Err():
+			return fmt.Errorf("发布确认失败: %w", err)
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	return nil
+}
+
+// subjectToken 将设备键转换为合法的主题片段（设备 ID 为数字，无法解析 ID 时的 URL 需要替换特殊字符）
+func subjectToken(key string) string {
+	return strings.Map(func(r rune) rune {
+		switch r {
+		case '.', '*', '>', ' ', '\t', '\r', '\n':
+			return '_'
+		}
+		return r
+	}, key)
+}
@@ -0,0 +1,163 @@
+package sink
+
+import (
+	"context"
+	"encoding/json"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/nats-io/nats-server/v2/server"
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+)
+
+// startNATS 启动进程内的 JetStream 服务器并返回连接
+func startNATS(t *testing.T) *nats.Conn {
+	t.Helper()
+	ns, err := server.NewServer(&server.Options{
+		Host:      "127.0.0.1",
+		Port:      -1,
+		JetStream: true,
+		StoreDir:  t.TempDir(),
+		NoLog:     true,
+		NoSigs:    true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	go ns.Start()
+	if !ns.ReadyForConnections(5 * time.Second) {
+		t.Fatal("NATS 服务器启动超时")
+	}
+	t.Cleanup(ns.Shutdown)
+
+	nc, err := nats.Connect("", nats.InProcessServer(ns))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(nc.Close)
+	return nc
+}
+
+// streamMessages 读取 Stream 中的所有消息
+func streamMessages(t *testing.T, nc *nats.Conn, stream string) []jetstream.Msg {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	js, err := jetstream.New(nc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s, err := js.Stream(ctx, stream)
+	if err != nil {
+		t.Fatal(err)
+	}
+	info, err := s.Info(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	n := int(info.State.Msgs)
+	if n == 0 {
+		return nil
+	}
+	consumer, err := s.OrderedConsumer(ctx, jetstream.OrderedConsumerConfig{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	batch, err := consumer.Fetch(n, jetstream.FetchMaxWait(2*time.Second))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var msgs []jetstream.Msg
+	for msg := range batch.Messages() {
+		msgs = append(msgs, msg)
+	}
+	return msgs
+}
+
+func TestNATSPublishesBatchesWithAck(t *testing.T) {
+	nc := startNATS(t)
+	s, err := NewNATS(NATSOptions{
+		Conn:          nc,
+		Subject:       "test.phones",
+		Stream:        "PHONES",
+		Outbox:        filepath.Join(t.TempDir(), "outbox.db"),
+		BatchSize:     2,
+		FlushInterval: time.Hour,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	writeAll(t, s, testPhone("1", "6.1"), testPhone("2", "6.7"))
+	waitFor(t, "第一批发布", func() bool { return s.delivery.outbox.Len() == 0 })
+	writeAll(t, s, testPhone("1", "6.3"))
+	if err := s.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if !nc.IsConnected() {
+		t.Error("Close 不应关闭外部传入的连接")
+	}
+
+	msgs := streamMessages(t, nc, "PHONES")
+	if len(msgs) != 3 {
+		t.Fatalf("Stream 中有 %d 条消息，期望 3", len(msgs))
+	}
+	for i, want := range []struct{ subject, event string }{
+		{"test.phones.1", EventCreated},
+		{"test.phones.2", EventCreated},
+		{"test.phones.1", EventUpdated},
+	} {
+		msg := msgs[i]
+		if msg.Subject() != want.subject || msg.Headers().Get(NATSEventHeader) != want.event {
+			t.Errorf("消息 %d = %s/%s，期望 %s/%s", i, msg.Subject(), msg.Headers().Get(NATSEventHeader), want.subject, want.event)
+		}
+		var event Event
+		if err := json.Unmarshal(msg.Data(), &event); err != nil || event.Type != want.event {
+			t.Errorf("消息 %d 的事件 = %+v (%v)", i, event, err)
+		}
+	}
+}
+
+func TestNATSReplaysOutboxAfterReopen(t *testing.T) {
+	nc := startNATS(t)
+	outbox := filepath.Join(t.TempDir(), "outbox.db")
+
+	// Stream 尚不存在，发布得不到确认，消息留在发件箱中
+	s, err := NewNATS(NATSOptions{Conn: nc, Subject: "test.phones", Outbox: outbox, BatchSize: 10, FlushInterval: time.Hour})
+	if err != nil {
+		t.Fatal(err)
+	}
+	writeAll(t, s, testPhone("1", "6.1"), testPhone("2", "6.7"))
+	if err := s.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	s, err = NewNATS(NATSOptions{
+		Conn:          nc,
+		Subject:       "test.phones",
+		Stream:        "PHONES",
+		Outbox:        outbox,
+		BatchSize:     10,
+		FlushInterval: 20 * time.Millisecond,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	if got := s.delivery.outbox.Len(); got != 2 {
+		t.Fatalf("重新打开后发件箱 %d 条，期望 2", got)
+	}
+	waitFor(t, "重新打开后发布", func() bool { return s.delivery.outbox.Len() == 0 })
+
+	msgs := streamMessages(t, nc, "PHONES")
+	if len(msgs) != 2 {
+		t.Fatalf("Stream 中有 %d 条消息，期望 2", len(msgs))
+	}
+	for i, device := range []string{"1", "2"} {
+		if got := msgs[i].Headers().Get(NATSDeviceHeader); got != device {
+			t.Errorf("消息 %d 的设备 = %s，期望 %s", i, got, device)
+		}
+	}
+}
@@ -0,0 +1,176 @@
+package sink
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/parquet-go/parquet-go"
+	"github.com/parquet-go/parquet-go/compress"
+	"github.com/yangbin1322/go-gsmarena/crawler"
+)
+
+// DefaultParquetRowGroupRows Parquet 默认每个 Row Group 的行数
+const DefaultParquetRowGroupRows = 10000
+
+// parquetRow Parquet 文件的行结构（列顺序与名称即对外 schema，修改需保持兼容）
+type parquetRow struct {
+	URL         string `parquet:"url"`
+	DeviceID    string `parquet:"device_id"`
+	ModelName   string `parquet:"model_name"`
+	Brand       string `parquet:"brand,dict"`
+	ReleaseDate string `parquet:"release_date"`
+	CrawledAt   string `parquet:"crawled_at"`
+
+	// 规格参数中解析出的数值列，无法解析时为 null
+	ReleaseYear       *int32   `parquet:"release_year,optional"`
+	DisplaySizeInches *float64 `parquet:"display_size_inches,optional"`
+	DisplayWidthPx    *int32   `parquet:"display_width_px,optional"`
+	DisplayHeightPx   *int32   `parquet:"display_height_px,optional"`
+	BatteryMAh        *int32   `parquet:"battery_mah,optional"`
+	WeightGrams       *float64 `parquet:"weight_grams,optional"`
+	RAMGB             *float64 `parquet:"ram_gb,optional"`
+	StorageGB         *float64 `parquet:"storage_gb,optional"`
+	PriceEUR          *float64 `parquet:"price_eur,optional"`
+	Has5G             bool     `parquet:"has_5g"`
+
+	// 原始规格参数
+	Specs map[string]string `parquet:"specs"`
+
+	// 记录格式版本，见 crawler.SchemaVersion
+	SchemaVersion int32 `parquet:"schema_version"`
+}
+
+// ParquetOptions Parquet 输出配置
+type ParquetOptions struct {
+	// RowGroupRows 每个 Row Group 的最大行数，默认 DefaultParquetRowGroupRows
+	RowGroupRows int64
+	// Compression 压缩算法: snappy（默认）/ zstd / none
+	Compression string
+}
+
+// Parquet 将手机数据写入 Parquet 文件
+// Parquet 文件在 Close 写入 footer 后才可读，不支持追加，因此每次运行写入一个新文件：
+// 文件名中插入运行时间戳，如 results.parquet -> results-20251202T103045.parquet
+type Parquet struct {
+	path   string
+	file   *os.File
+	writer *parquet.GenericWriter[parquetRow]
+	mu     sync.Mutex
+}
+
+// NewParquet 创建本次运行的 Parquet 文件
+func NewParquet(path string, opts ParquetOptions) (*Parquet, error) {
+	if opts.RowGroupRows <= 0 {
+		opts.RowGroupRows = DefaultParquetRowGroupRows
+	}
+
+	var codec compress.Codec
+	switch strings.ToLower(opts.Compression) {
+	case "", "snappy":
+		codec = &parquet.Snappy
+	case "zstd":
+		codec = &parquet.Zstd
+	case "none":
+		codec = &parquet.Uncompressed
+	default:
+		return nil, fmt.Errorf("不支持的 Parquet 压缩算法: %q", opts.Compression)
+	}
+
+	ext := filepath.Ext(path)
+	path = strings.TrimSuffix(path, ext) + "-" + time.Now().Format("20060102T150405") + ext
+
+	file, err := os.Create(path)
+	if err != nil {
+		return nil, fmt.Errorf("创建 Parquet 文件失败: %w", err)
+	}
+
+	writer := parquet.NewGenericWriter[parquetRow](file,
+		parquet.MaxRowsPerRowGroup(opts.RowGroupRows),
+		parquet.Compression(codec),
+		parquet.CreatedBy("go-gsmarena", "", ""),
+	)
+	return &Parquet{path: path, file: file, writer: writer}, nil
+}
+
+// Write 写入一条手机数据
+func (s *Parquet) Write(phone crawler.Phone) error {
+	n := crawler.Normalize(phone)
+	row := parquetRow{
+		SchemaVersion:     int32(phone.SchemaVersion),
+		URL:               phone.URL,
+		DeviceID:          crawler.DeviceID(phone.URL),
+		ModelName:         phone.ModelName,
+		Brand:             phone.Brand,
+		ReleaseDate:       phone.ReleaseDate,
+		CrawledAt:         phone.CrawledAt,
+		ReleaseYear:       optionalInt(n.ReleaseYear),
+		DisplaySizeInches: optionalFloat(n.DisplaySizeInches),
+		DisplayWidthPx:    optionalInt(n.DisplayWidthPx),
+		DisplayHeightPx:   optionalInt(n.DisplayHeightPx),
+		BatteryMAh:        optionalInt(n.BatteryMAh),
+		WeightGrams:       optionalFloat(n.WeightGrams),
+		RAMGB:             optionalFloat(n.RAMGB),
+		StorageGB:         optionalFloat(n.StorageGB),
+		PriceEUR:          optionalFloat(n.PriceEUR),
+		Has5G:             n.Has5G,
+		Specs:             phone.Specs,
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, err := s.writer.Write([]parquetRow{row}); err != nil {
+		return fmt.Errorf("写入 Parquet 失败: %w", err)
+	}
+	return nil
+}
+
+// Flush 行数据按 Row Group 缓冲，达到 RowGroupRows 时自动写出，这里不强制切分 Row Group
+// 文件在 Close 写入 footer 前不可读，即使写出 Row Group 崩溃后也无法使用，
+// 因此 Parquet 实现 Deferred：记录在 Close 成功后才清除待导出标记
+func (s *Parquet) Flush() error {
+	return nil
+}
+
+// Deferred 数据在 Close 时才落盘（见 Deferred 接口）
+func (s *Parquet) Deferred() {}
+
+// Path 返回本次运行的 Parquet 文件路径
+func (s *Parquet) Path() string {
+	return s.path
+}
+
+// Close 写出剩余数据和文件 footer 并关闭文件
+func (s *Parquet) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.writer.Close(); err != nil {
+		s.file.Close()
+		return fmt.Errorf("写入 Parquet footer 失败: %w", err)
+	}
+	if err := s.file.Sync(); err != nil {
+		s.file.Close()
+		return fmt.Errorf("同步 Parquet 文件失败: %w", err)
+	}
+	return s.file.Close()
+}
+
+// optionalInt 零值视为缺失
+func optionalInt(v int) *int32 {
+	if v == 0 {
+		return nil
+	}
+	i := int32(v)
+	return &i
+}
+
+// optionalFloat 零值视为缺失
+func optionalFloat(v float64) *float64 {
+	if v == 0 {
+		return nil
+	}
+	return &v
+}
@@ -0,0 +1,463 @@
+package sink
+
+import (
+	"bufio"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/yangbin1322/go-gsmarena/crawler"
+)
+
+// partSuffix 正在写入的分片文件后缀，分片封存（压缩、写入清单）后删除
+const partSuffix = ".part"
+
+// RotateOptions JSONL 分片配置
+type RotateOptions struct {
+	// MaxBytes 单个分片的最大字节数（压缩前），0 表示不限制
+	MaxBytes int64
+	// MaxRecords 单个分片的最大记录数，0 表示不限制
+	MaxRecords int
+	// Compression 分片压缩算法: none（默认）/ gzip / zstd
+	Compression string
+}
+
+// ChunkInfo 清单中的分片信息
+type ChunkInfo struct {
+	File           string `json:"file"`                  // 分片文件名（相对清单所在目录）
+	Records        int    `json:"records"`               // 记录数
+	Bytes          int64  `json:"bytes"`                 // 文件大小（压缩后）
+	SHA256         string `json:"sha256"`                // 文件校验和
+	Compression    string `json:"compression,omitempty"` // 压缩算法
+	FirstCrawledAt string `json:"first_crawled_at"`      // 最早的抓取时间
+	LastCrawledAt  string `json:"last_crawled_at"`       // 最晚的抓取时间
+	CreatedAt      string `json:"created_at"`            // 分片封存时间
+}
+
+// Manifest 分片清单，按封存顺序列出所有分片
+type Manifest struct {
+	Chunks []ChunkInfo `json:"chunks"`
+}
+
+// ManifestPath 返回输出路径对应的清单文件路径，如 results.jsonl -> results.manifest.json
+func ManifestPath(path string) string {
+	return strings.TrimSuffix(path, filepath.Ext(path)) + ".manifest.json"
+}
+
+// ReadManifest 读取清单文件，文件不存在时返回空清单
+func ReadManifest(path string) (*Manifest, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return &Manifest{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("读取清单失败: %w", err)
+	}
+	var m Manifest
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, fmt.Errorf("解析清单失败: %s: %w", path, err)
+	}
+	return &m, nil
+}
+
+// write 先写临时文件再重命名，保证清单始终完整
+func (m *Manifest) write(path string) error {
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return fmt.Errorf("清单序列化失败: %w", err)
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("写入清单失败: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return fmt.Errorf("写入清单失败: %w", err)
+	}
+	return nil
+}
+
+// add 添加（或替换同名）分片
+func (m *Manifest) add(chunk ChunkInfo) {
+	for i := range m.Chunks {
+		if m.Chunks[i].File == chunk.File {
+			m.Chunks[i] = chunk
+			return
+		}
+	}
+	m.Chunks = append(m.Chunks, chunk)
+}
+
+// OpenChunk 打开分片文件，按扩展名（.gz / .zst）自动解压
+func OpenChunk(path string) (io.ReadCloser, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("打开分片失败: %w", err)
+	}
+	switch filepath.Ext(path) {
+	case ".gz":
+		r, err := gzip.NewReader(f)
+		if err != nil {
+			f.Close()
+			return nil, fmt.Errorf("打开 gzip 分片失败: %s: %w", path, err)
+		}
+		return readCloser{Reader: r, close: func() error { r.Close(); return f.Close() }}, nil
+	case ".zst":
+		r, err := zstd.NewReader(f)
+		if err != nil {
+			f.Close()
+			return nil, fmt.Errorf("打开 zstd 分片失败: %s: %w", path, err)
+		}
+		return readCloser{Reader: r, close: func() error { r.Close(); return f.Close() }}, nil
+	default:
+		return f, nil
+	}
+}
+
+// readCloser 关闭时同时关闭解压器和底层文件
+type readCloser struct {
+	io.Reader
+	close func() error
+}
+
+func (r readCloser) Close() error {
+	return r.close()
+}
+
+// RotatingJSONL 按大小 / 记录数 / 运行切分的 JSONL 输出
+//
+// 每次运行写入新的分片 <名称>-<运行时间戳>-<序号>.jsonl，写入过程中为未压缩的 .part 文件，
+// 达到上限或关闭时封存：按配置压缩为 .jsonl.gz / .jsonl.zst，并将记录数、校验和、时间范围写入清单。
+// 启动时遗留的 .part 文件（上次运行崩溃）会先截断不完整的行再封存。
+type RotatingJSONL struct {
+	path     string // 配置的输出路径，如 results.jsonl
+	opts     RotateOptions
+	run      string // 本次运行时间戳
+	seq      int    // 当前分片序号
+	manifest *Manifest
+
+	part    *os.File // 当前分片，尚未写入时为 nil
+	records int
+	bytes   int64
+	mu      sync.Mutex
+}
+
+// NewRotatingJSONL 创建分片输出，并封存上次运行遗留的分片
+func NewRotatingJSONL(path string, opts RotateOptions) (*RotatingJSONL, error) {
+	switch strings.ToLower(opts.Compression) {
+	case "", "none":
+		opts.Compression = ""
+	case "gzip", "zstd":
+		opts.Compression = strings.ToLower(opts.Compression)
+	default:
+		return nil, fmt.Errorf("不支持的压缩算法: %q", opts.Compression)
+	}
+
+	manifest, err := ReadManifest(ManifestPath(path))
+	if err != nil {
+		return nil, err
+	}
+	s := &RotatingJSONL{
+		path:     path,
+		opts:     opts,
+		run:      time.Now().Format("20060102T150405"),
+		manifest: manifest,
+	}
+
+	stem := strings.TrimSuffix(path, filepath.Ext(path))
+	orphans, err := filepath.Glob(stem + "-*" + filepath.Ext(path) + partSuffix)
+	if err != nil {
+		return nil, fmt.Errorf("查找遗留分片失败: %w", err)
+	}
+	sort.Strings(orphans)
+	for _, orphan := range orphans {
+		log.Printf("[恢复] 封存上次运行遗留的分片: %s", orphan)
+		if err := s.seal(orphan); err != nil {
+			return nil, err
+		}
+	}
+	return s, nil
+}
+
+// Write 写入一条手机数据，达到分片上限时封存当前分片
+func (s *RotatingJSONL) Write(phone crawler.Phone) error {
+	data, err := json.Marshal(phone)
+	if err != nil {
+		return fmt.Errorf("JSON 序列化失败: %w", err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.part == nil {
+		name := s.nextPart()
+		if s.part, err = os.OpenFile(name, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644); err != nil {
+			return fmt.Errorf("创建分片失败: %w", err)
+		}
+		s.records, s.bytes = 0, 0
+	}
+
+	n, err := s.part.Write(append(data, '\n'))
+	if err != nil {
+		return fmt.Errorf("写入分片失败: %w", err)
+	}
+	s.records++
+	s.bytes += int64(n)
+
+	if (s.opts.MaxRecords > 0 && s.records >= s.opts.MaxRecords) ||
+		(s.opts.MaxBytes > 0 && s.bytes >= s.opts.MaxBytes) {
+		return s.rotate()
+	}
+	return nil
+}
+
+// nextPart 返回下一个分片的 .part 文件名，调用方需持有锁
+// 同一秒内启动的两次运行时间戳相同，跳过已存在的分片，避免覆盖上一次运行的分片
+func (s *RotatingJSONL) nextPart() string {
+	stem, ext := strings.TrimSuffix(s.path, filepath.Ext(s.path)), filepath.Ext(s.path)
+	for {
+		s.seq++
+		name := fmt.Sprintf("%s-%s-%05d%s", stem, s.run, s.seq, ext)
+		if !s.chunkExists(name) {
+			return name + partSuffix
+		}
+	}
+}
+
+// chunkExists 检查分片（任意压缩算法）是否已在清单或磁盘上
+func (s *RotatingJSONL) chunkExists(name string) bool {
+	for _, suffix := range []string{"", ".gz", ".zst", partSuffix} {
+		file := filepath.Base(name) + suffix
+		for _, chunk := range s.manifest.Chunks {
+			if chunk.File == file {
+				return true
+			}
+		}
+		if _, err := os.Stat(name + suffix); err == nil {
+			return true
+		}
+	}
+	return false
+}
+
+// Flush 每条记录直接写入分片文件，无需额外刷新
+func (s *RotatingJSONL) Flush() error {
+	return nil
+}
+
+// Existing 扫描清单中的所有分片及当前分片，返回 urls 中已写入的 URL
+func (s *RotatingJSONL) Existing(urls map[string]bool) (map[string]bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	found := make(map[string]bool)
+	match := func(line []byte) {
+		var record struct {
+			URL string `json:"url"`
+		}
+		if json.Unmarshal(line, &record) == nil && urls[record.URL] {
+			found[record.URL] = true
+		}
+	}
+
+	dir := filepath.Dir(s.path)
+	for _, chunk := range s.manifest.Chunks {
+		r, err := OpenChunk(filepath.Join(dir, chunk.File))
+		if err != nil {
+			return nil, err
+		}
+		err = eachLine(r, match)
+		r.Close()
+		if err != nil {
+			return nil, fmt.Errorf("读取分片失败: %s: %w", chunk.File, err)
+		}
+	}
+	if s.part != nil {
+		if err := scanLines(s.part.Name(), match); err != nil {
+			return nil, err
+		}
+	}
+	return found, nil
+}
+
+// Close 封存当前分片
+func (s *RotatingJSONL) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.part == nil {
+		return nil
+	}
+	return s.rotate()
+}
+
+// rotate 关闭并封存当前分片，调用方需持有锁
+func (s *RotatingJSONL) rotate() error {
+	name := s.part.Name()
+	if err := s.part.Sync(); err != nil {
+		s.part.Close()
+		return fmt.Errorf("同步分片失败: %w", err)
+	}
+	if err := s.part.Close(); err != nil {
+		return fmt.Errorf("关闭分片失败: %w", err)
+	}
+	s.part = nil
+	return s.seal(name)
+}
+
+// seal 将 .part 文件压缩为最终分片，写入清单后删除 .part 文件
+// 中途崩溃时 .part 文件仍然存在，下次启动会重新封存（清单按文件名替换，不会重复）
+func (s *RotatingJSONL) seal(partPath string) error {
+	final := strings.TrimSuffix(partPath, partSuffix)
+	switch s.opts.Compression {
+	case "gzip":
+		final += ".gz"
+	case "zstd":
+		final += ".zst"
+	}
+
+	in, err := os.Open(partPath)
+	if err != nil {
+		return fmt.Errorf("打开分片失败: %w", err)
+	}
+	defer in.Close()
+
+	out, err := os.Create(final)
+	if err != nil {
+		return fmt.Errorf("创建分片失败: %w", err)
+	}
+	defer out.Close()
+
+	hash := sha256.New()
+	counter := &countingWriter{w: io.MultiWriter(out, hash)}
+
+	var w io.WriteCloser = nopWriteCloser{counter}
+	switch s.opts.Compression {
+	case "gzip":
+		w = gzip.NewWriter(counter)
+	case "zstd":
+		if w, err = zstd.NewWriter(counter); err != nil {
+			return fmt.Errorf("创建 zstd 压缩器失败: %w", err)
+		}
+	}
+
+	chunk := ChunkInfo{File: filepath.Base(final), Compression: s.opts.Compression}
+	reader := bufio.NewReader(in)
+	for {
+		line, err := reader.ReadBytes('\n')
+		if errors.Is(err, io.EOF) {
+			// 不以换行结束的半行是崩溃时未写完的记录，丢弃
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("读取分片失败: %w", err)
+		}
+		if _, err := w.Write(line); err != nil {
+			return fmt.Errorf("写入分片失败: %w", err)
+		}
+
+		var record struct {
+			CrawledAt string `json:"crawled_at"`
+		}
+		if json.Unmarshal(line, &record) == nil && record.CrawledAt != "" {
+			if chunk.FirstCrawledAt == "" || record.CrawledAt < chunk.FirstCrawledAt {
+				chunk.FirstCrawledAt = record.CrawledAt
+			}
+			if record.CrawledAt > chunk.LastCrawledAt {
+				chunk.LastCrawledAt = record.CrawledAt
+			}
+		}
+		chunk.Records++
+	}
+
+	if err := w.Close(); err != nil {
+		return fmt.Errorf("写入分片失败: %w", err)
+	}
+	if err := out.Sync(); err != nil {
+		return fmt.Errorf("同步分片失败: %w", err)
+	}
+
+	if chunk.Records > 0 {
+		chunk.Bytes = counter.n
+		chunk.SHA256 = hex.EncodeToString(hash.Sum(nil))
+		chunk.CreatedAt = time.Now().Format(time.RFC3339)
+		s.manifest.add(chunk)
+		if err := s.manifest.write(ManifestPath(s.path)); err != nil {
+			return err
+		}
+		log.Printf("[分片] %s: %d 条记录, %d 字节", chunk.File, chunk.Records, chunk.Bytes)
+	} else {
+		out.Close()
+		os.Remove(final)
+	}
+	return os.Remove(partPath)
+}
+
+// eachLine 逐行读取（已封存的分片），fn 收到的行包含结尾的换行符
+func eachLine(r io.Reader, fn func(line []byte)) error {
+	reader := bufio.NewReader(r)
+	for {
+		line, err := reader.ReadBytes('\n')
+		if len(line) > 0 {
+			fn(line)
+		}
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// countingWriter 统计写入的字节数
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
+
+// nopWriteCloser 不压缩时直接写入文件
+type nopWriteCloser struct {
+	io.Writer
+}
+
+func (nopWriteCloser) Close() error {
+	return nil
+}
+
+// parseSize 解析大小配置，如 "100MB"、"1GB"、"512KB" 或字节数
+func parseSize(s string) (int64, error) {
+	s = strings.ToUpper(strings.TrimSpace(s))
+	multiplier := int64(1)
+	for _, unit := range []struct {
+		suffix string
+		value  int64
+	}{{"GB", 1 << 30}, {"MB", 1 << 20}, {"KB", 1 << 10}, {"B", 1}} {
+		if v, ok := strings.CutSuffix(s, unit.suffix); ok {
+			s, multiplier = strings.TrimSpace(v), unit.value
+			break
+		}
+	}
+	n, err := strconv.ParseInt(s, 10, 64)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("大小格式错误: %q", s)
+	}
+	return n * multiplier, nil
+}
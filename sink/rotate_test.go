@@ -0,0 +1,249 @@
+package sink
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/yangbin1322/go-gsmarena/crawler"
+)
+
+// rotatePhones 构造序列化后长度相同的记录，抓取时间依次递增
+func rotatePhones(n int) []crawler.Phone {
+	base := time.Date(2024, 6, 1, 8, 0, 0, 0, time.UTC)
+	phones := make([]crawler.Phone, n)
+	for i := range phones {
+		phones[i] = testPhone(fmt.Sprint(i+1), "6.1 inches")
+		phones[i].CrawledAt = base.Add(time.Duration(i) * time.Minute).Format(time.RFC3339)
+	}
+	return phones
+}
+
+// readChunk 读取分片中的记录 URL
+func readChunk(t *testing.T, path string) []string {
+	t.Helper()
+	r, err := OpenChunk(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	data, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var urls []string
+	for _, line := range strings.Split(strings.TrimSuffix(string(data), "\n"), "\n") {
+		var phone crawler.Phone
+		if err := json.Unmarshal([]byte(line), &phone); err != nil {
+			t.Fatalf("%s: %v", path, err)
+		}
+		urls = append(urls, phone.URL)
+	}
+	return urls
+}
+
+func TestRotatingJSONLManifest(t *testing.T) {
+	phones := rotatePhones(5)
+	line, _ := json.Marshal(phones[0])
+	size := int64(len(line) + 1)
+
+	for _, tc := range []struct {
+		name   string
+		opts   RotateOptions
+		ext    string
+		chunks []int // 每个分片的记录数
+	}{
+		// 第二条记录写入后达到上限，最后一条在关闭时封存
+		{"按大小", RotateOptions{MaxBytes: size + 1}, ".jsonl", []int{2, 2, 1}},
+		{"按记录数", RotateOptions{MaxRecords: 3}, ".jsonl", []int{3, 2}},
+		{"gzip", RotateOptions{MaxBytes: size + 1, Compression: "gzip"}, ".jsonl.gz", []int{2, 2, 1}},
+		{"zstd", RotateOptions{MaxBytes: size + 1, Compression: "ZSTD"}, ".jsonl.zst", []int{2, 2, 1}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			dir := t.TempDir()
+			path := filepath.Join(dir, "results.jsonl")
+			s, err := NewRotatingJSONL(path, tc.opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			writeAll(t, s, phones...)
+			if err := s.Close(); err != nil {
+				t.Fatal(err)
+			}
+
+			manifest, err := ReadManifest(ManifestPath(path))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(manifest.Chunks) != len(tc.chunks) {
+				t.Fatalf("分片数 = %d，期望 %d: %+v", len(manifest.Chunks), len(tc.chunks), manifest.Chunks)
+			}
+
+			next := 0
+			for i, chunk := range manifest.Chunks {
+				if !strings.HasPrefix(chunk.File, "results-") || !strings.HasSuffix(chunk.File, fmt.Sprintf("-%05d%s", i+1, tc.ext)) {
+					t.Errorf("分片 %d 文件名 = %s", i+1, chunk.File)
+				}
+				if chunk.Records != tc.chunks[i] {
+					t.Errorf("分片 %d 记录数 = %d，期望 %d", i+1, chunk.Records, tc.chunks[i])
+				}
+				if want := strings.ToLower(tc.opts.Compression); chunk.Compression != want {
+					t.Errorf("分片 %d 压缩算法 = %q，期望 %q", i+1, chunk.Compression, want)
+				}
+				first, last := phones[next].CrawledAt, phones[next+chunk.Records-1].CrawledAt
+				if chunk.FirstCrawledAt != first || chunk.LastCrawledAt != last {
+					t.Errorf("分片 %d 时间范围 = %s ~ %s，期望 %s ~ %s", i+1, chunk.FirstCrawledAt, chunk.LastCrawledAt, first, last)
+				}
+				if _, err := time.Parse(time.RFC3339, chunk.CreatedAt); err != nil {
+					t.Errorf("分片 %d 封存时间 = %q", i+1, chunk.CreatedAt)
+				}
+
+				// 大小和校验和与磁盘上的文件一致
+				data, err := os.ReadFile(filepath.Join(dir, chunk.File))
+				if err != nil {
+					t.Fatal(err)
+				}
+				sum := sha256.Sum256(data)
+				if chunk.Bytes != int64(len(data)) || chunk.SHA256 != hex.EncodeToString(sum[:]) {
+					t.Errorf("分片 %d 大小/校验和 = %d/%s，文件为 %d/%x", i+1, chunk.Bytes, chunk.SHA256, len(data), sum)
+				}
+
+				// 记录按写入顺序分布在各分片中
+				urls := readChunk(t, filepath.Join(dir, chunk.File))
+				for j, url := range urls {
+					if url != phones[next+j].URL {
+						t.Errorf("分片 %d 第 %d 条 = %s，期望 %s", i+1, j+1, url, phones[next+j].URL)
+					}
+				}
+				next += chunk.Records
+			}
+
+			if parts, _ := filepath.Glob(filepath.Join(dir, "*"+partSuffix)); len(parts) != 0 {
+				t.Errorf("关闭后仍有未封存的分片: %v", parts)
+			}
+		})
+	}
+}
+
+func TestRotatingJSONLNextRunAppends(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "results.jsonl")
+	phones := rotatePhones(3)
+
+	s, err := NewRotatingJSONL(path, RotateOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	writeAll(t, s, phones[:2]...)
+	if err := s.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// 下一次运行追加新分片，并能查到之前写入的 URL
+	s, err = NewRotatingJSONL(path, RotateOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	writeAll(t, s, phones[2])
+	found, err := s.Existing(map[string]bool{phones[0].URL: true, phones[2].URL: true, "https://example.com/": true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(found) != 2 || !found[phones[0].URL] || !found[phones[2].URL] {
+		t.Errorf("Existing = %v，期望已封存和当前分片中的 URL", found)
+	}
+	if err := s.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	manifest, err := ReadManifest(ManifestPath(path))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(manifest.Chunks) != 2 || manifest.Chunks[0].Records != 2 || manifest.Chunks[1].Records != 1 {
+		t.Errorf("清单 = %+v，期望两个分片（2 条、1 条）", manifest.Chunks)
+	}
+}
+
+func TestRotatingJSONLSealsOrphanPart(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "results.jsonl")
+	phones := rotatePhones(2)
+
+	// 上次运行崩溃：两条完整记录和一条写了一半的记录
+	var data []byte
+	for _, phone := range phones {
+		line, _ := json.Marshal(phone)
+		data = append(data, line...)
+		data = append(data, '\n')
+	}
+	data = append(data, `{"model_name":"Phone 3","url":"https://www.gsmarena.com/te`...)
+	orphan := filepath.Join(dir, "results-20240601T080000-00001.jsonl"+partSuffix)
+	if err := os.WriteFile(orphan, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	s, err := NewRotatingJSONL(path, RotateOptions{Compression: "gzip"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+
+	if _, err := os.Stat(orphan); !os.IsNotExist(err) {
+		t.Errorf("遗留的 .part 文件未删除: %v", err)
+	}
+	manifest, err := ReadManifest(ManifestPath(path))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(manifest.Chunks) != 1 {
+		t.Fatalf("清单 = %+v，期望 1 个分片", manifest.Chunks)
+	}
+	chunk := manifest.Chunks[0]
+	if chunk.File != "results-20240601T080000-00001.jsonl.gz" || chunk.Records != 2 {
+		t.Errorf("封存的分片 = %+v，期望 2 条记录（丢弃不完整的行）", chunk)
+	}
+	if urls := readChunk(t, filepath.Join(dir, chunk.File)); len(urls) != 2 || urls[1] != phones[1].URL {
+		t.Errorf("分片内容 = %v", urls)
+	}
+}
+
+func TestRotatingJSONLInvalidCompression(t *testing.T) {
+	if _, err := NewRotatingJSONL(filepath.Join(t.TempDir(), "results.jsonl"), RotateOptions{Compression: "brotli"}); err == nil {
+		t.Error("不支持的压缩算法应返回错误")
+	}
+}
+
+func TestParseSize(t *testing.T) {
+	for _, tc := range []struct {
+		in   string
+		want int64
+	}{
+		{"100MB", 100 << 20},
+		{"1gb", 1 << 30},
+		{" 512 KB ", 512 << 10},
+		{"2048B", 2048},
+		{"4096", 4096},
+		{"MB", -1},
+		{"-1MB", -1},
+		{"1.5GB", -1},
+	} {
+		got, err := parseSize(tc.in)
+		if tc.want < 0 {
+			if err == nil {
+				t.Errorf("parseSize(%q) = %d，期望返回错误", tc.in, got)
+			}
+			continue
+		}
+		if err != nil || got != tc.want {
+			t.Errorf("parseSize(%q) = %d, %v，期望 %d", tc.in, got, err, tc.want)
+		}
+	}
+}
@@ -0,0 +1,353 @@
+// Package sink 提供抓取结果的输出实现
+package sink
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/yangbin1322/go-gsmarena/crawler"
+	"github.com/yangbin1322/go-gsmarena/storage"
+)
+
+// Sink 抓取结果输出接口
+type Sink interface {
+	// Write 写入一条手机数据
+	Write(phone crawler.Phone) error
+	// Flush 将缓冲区中的数据交给底层存储（文件、数据库等）
+	Flush() error
+	// Close 刷新并关闭输出
+	Close() error
+}
+
+// Existing 可选接口：返回 urls 中已写入该输出的 URL
+// 恢复检查时用于避免重复写入；未实现的输出会收到全部待导出记录（需自行保证幂等）
+type Existing interface {
+	Existing(urls map[string]bool) (map[string]bool, error)
+}
+
+// Deferred 可选接口：写入的数据要到 Close 时才真正落盘的输出（如 Parquet 在 Close 写入 footer 前文件不可读）
+// Multi 对写入这类输出的记录保留待导出标记，Close 成功后才清除，崩溃后由 Recover 补写
+type Deferred interface {
+	Deferred()
+}
+
+// Open 根据配置创建输出，格式为 "类型[:路径][?参数=值&...]"
+// 支持: jsonl:results.jsonl、csv:results.csv、sqlite:results.db、stdout、
+// jsonl:results.jsonl?max_size=100MB&max_records=N&compress=gzip|zstd（或 rotate=run 仅按运行切分）、
+// parquet:results.parquet?compression=zstd&rows=50000、
+// webhook:https://example.com/hook?secret_env=WEBHOOK_SECRET&batch=50&interval=2s&outbox=webhook-outbox.db、
+// nats:nats://127.0.0.1:4222?subject=gsmarena.phones&stream=GSMARENA&outbox=nats-outbox.db
+func Open(spec string) (Sink, error) {
+	spec, rawQuery, _ := strings.Cut(spec, "?")
+	kind, path, _ := strings.Cut(spec, ":")
+	kind = strings.ToLower(strings.TrimSpace(kind))
+	path = strings.TrimSpace(path)
+
+	params, err := url.ParseQuery(rawQuery)
+	if err != nil {
+		return nil, fmt.Errorf("输出参数格式错误: %w", err)
+	}
+
+	if kind != "stdout" && path == "" {
+		return nil, fmt.Errorf("输出 %q 缺少文件路径", spec)
+	}
+
+	switch kind {
+	case "jsonl":
+		// 指定任一分片参数时按分片输出
+		if !params.Has("rotate") && !params.Has("max_size") && !params.Has("max_records") && !params.Has("compress") {
+			return NewJSONL(path)
+		}
+		if rotate := params.Get("rotate"); rotate != "" && rotate != "run" {
+			return nil, fmt.Errorf("不支持的分片方式: %q", rotate)
+		}
+		opts := RotateOptions{Compression: params.Get("compress")}
+		if size := params.Get("max_size"); size != "" {
+			if opts.MaxBytes, err = parseSize(size); err != nil {
+				return nil, fmt.Errorf("max_size 参数格式错误: %w", err)
+			}
+		}
+		if opts.MaxRecords, err = intParam(params, "max_records"); err != nil {
+			return nil, err
+		}
+		return NewRotatingJSONL(path, opts)
+	case "csv":
+		return NewCSV(path)
+	case "sqlite":
+		return NewSQLite(path)
+	case "stdout":
+		return NewStdout(), nil
+	case "parquet":
+		opts := ParquetOptions{Compression: params.Get("compression")}
+		if rows := params.Get("rows"); rows != "" {
+			if opts.RowGroupRows, err = strconv.ParseInt(rows, 10, 64); err != nil {
+				return nil, fmt.Errorf("rows 参数格式错误: %w", err)
+			}
+		}
+		return NewParquet(path, opts)
+	case "webhook":
+		opts := WebhookOptions{
+			URL:    path,
+			Secret: params.Get("secret"),
+			Outbox: params.Get("outbox"),
+		}
+		// 密钥可以放在环境变量中，避免出现在命令行参数里
+		if name := params.Get("secret_env"); name != "" {
+			opts.Secret = os.Getenv(name)
+		}
+		if opts.BatchSize, err = intParam(params, "batch"); err != nil {
+			return nil, err
+		}
+		if opts.FlushInterval, err = durationParam(params, "interval"); err != nil {
+			return nil, err
+		}
+		return NewWebhook(opts)
+	case "nats":
+		opts := NATSOptions{
+			URL:     path,
+			Subject: params.Get("subject"),
+			Stream:  params.Get("stream"),
+			Outbox:  params.Get("outbox"),
+		}
+		if opts.BatchSize, err = intParam(params, "batch"); err != nil {
+			return nil, err
+		}
+		if opts.FlushInterval, err = durationParam(params, "interval"); err != nil {
+			return nil, err
+		}
+		return NewNATS(opts)
+	default:
+		return nil, fmt.Errorf("不支持的输出类型: %q", kind)
+	}
+}
+
+// intParam 解析整数参数，未指定时返回 0
+func intParam(params url.Values, name string) (int, error) {
+	value := params.Get(name)
+	if value == "" {
+		return 0, nil
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, fmt.Errorf("%s 参数格式错误: %w", name, err)
+	}
+	return n, nil
+}
+
+// durationParam 解析时间参数（如 2s、500ms），未指定时返回 0
+func durationParam(params url.Values, name string) (time.Duration, error) {
+	value := params.Get(name)
+	if value == "" {
+		return 0, nil
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, fmt.Errorf("%s 参数格式错误: %w", name, err)
+	}
+	return d, nil
+}
+
+// SinkStats 单个输出的写入统计
+type SinkStats struct {
+	Name    string // 输出名称（配置字符串）
+	Written int    // 成功写入数量
+	Failed  int    // 写入失败数量
+}
+
+// Multi 将每条记录分发到所有已配置的输出
+// 单个输出失败不影响其他输出，错误按输出分别记录和统计
+// 包含 Deferred 输出时，记录在 Close 成功后才通过 Recover 传入的存储清除待导出标记
+type Multi struct {
+	names []string
+	sinks []Sink
+	stats []SinkStats
+	mu    sync.Mutex // 保护 stats 和 deferred
+
+	store    storage.RecordStore // Recover 传入的存储，用于确认延迟落盘的记录
+	deferred []string            // 已写入但等待 Deferred 输出落盘的记录 URL
+}
+
+// NewMulti 创建分发输出
+func NewMulti() *Multi {
+	return &Multi{}
+}
+
+// Add 添加一个输出，name 用于日志和统计
+func (m *Multi) Add(name string, s Sink) {
+	m.names = append(m.names, name)
+	m.sinks = append(m.sinks, s)
+	m.stats = append(m.stats, SinkStats{Name: name})
+}
+
+// Write 将记录写入所有输出并刷新
+// 任一输出失败时返回汇总错误，记录会保留在待导出队列中，由下次启动的 Recover 补齐
+func (m *Multi) Write(phone crawler.Phone) error {
+	var errs []error
+	for i, s := range m.sinks {
+		err := s.Write(phone)
+		if err == nil {
+			err = s.Flush()
+		}
+
+		m.mu.Lock()
+		if err != nil {
+			m.stats[i].Failed++
+		} else {
+			m.stats[i].Written++
+		}
+		m.mu.Unlock()
+
+		if err != nil {
+			log.Printf("[错误] 输出 %s 写入失败: %s: %v", m.names[i], phone.URL, err)
+			errs = append(errs, fmt.Errorf("%s: %w", m.names[i], err))
+		}
+	}
+	if len(errs) > 0 {
+		return errors.Join(errs...)
+	}
+	log.Printf("[保存] %s (%s)", phone.ModelName, phone.Brand)
+	if m.hasDeferred() {
+		m.mu.Lock()
+		m.deferred = append(m.deferred, phone.URL)
+		m.mu.Unlock()
+		return crawler.ErrDeferred
+	}
+	return nil
+}
+
+// hasDeferred 是否包含 Deferred 输出
+func (m *Multi) hasDeferred() bool {
+	for _, s := range m.sinks {
+		if _, ok := s.(Deferred); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// Flush 刷新所有输出
+func (m *Multi) Flush() error {
+	var errs []error
+	for i, s := range m.sinks {
+		if err := s.Flush(); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", m.names[i], err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Close 按添加顺序关闭所有输出
+// 全部输出关闭成功后清除延迟落盘记录的待导出标记，否则保留，由下次启动的 Recover 补写
+func (m *Multi) Close() error {
+	var errs []error
+	for i, s := range m.sinks {
+		if err := s.Close(); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", m.names[i], err))
+		}
+	}
+	if len(errs) > 0 {
+		return errors.Join(errs...)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.store == nil || len(m.deferred) == 0 {
+		return nil
+	}
+	for _, url := range m.deferred {
+		if err := m.store.AckRecord(url); err != nil {
+			return err
+		}
+	}
+	m.deferred = nil
+	return nil
+}
+
+// Stats 返回各输出的写入统计
+func (m *Multi) Stats() []SinkStats {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]SinkStats(nil), m.stats...)
+}
+
+// Recover 启动时的恢复检查：将已提交到存储但未导出的记录补写到各输出
+// 实现了 Existing 的输出只补写缺失的记录，全部输出成功后清除待导出标记（包含 Deferred 输出时在 Close 后清除）
+// 返回待恢复的记录数量；store 同时用于 Close 时确认延迟落盘的记录，应在写入前调用
+func (m *Multi) Recover(store storage.RecordStore) (int, error) {
+	m.mu.Lock()
+	m.store = store
+	m.mu.Unlock()
+
+	pending := make(map[string][]byte)
+	err := store.PendingRecords(func(url string, record []byte) error {
+		pending[url] = bytes.Clone(record)
+		return nil
+	})
+	if err != nil {
+		return 0, fmt.Errorf("读取待导出记录失败: %w", err)
+	}
+	if len(pending) == 0 {
+		return 0, nil
+	}
+
+	urls := make(map[string]bool, len(pending))
+	for url := range pending {
+		urls[url] = true
+	}
+
+	for i, s := range m.sinks {
+		exported := map[string]bool{}
+		if e, ok := s.(Existing); ok {
+			if exported, err = e.Existing(urls); err != nil {
+				return 0, fmt.Errorf("%s: 检查已导出记录失败: %w", m.names[i], err)
+			}
+		}
+
+		written := 0
+		for url, record := range pending {
+			if exported[url] {
+				continue
+			}
+			var phone crawler.Phone
+			if err := json.Unmarshal(record, &phone); err != nil {
+				return 0, fmt.Errorf("解析待导出记录失败: %s: %w", url, err)
+			}
+			// 只补全缺失的格式版本，保留记录原有的版本号
+			phone.Migrate()
+			if err := s.Write(phone); err != nil {
+				return 0, fmt.Errorf("%s: 补写记录失败: %w", m.names[i], err)
+			}
+			written++
+		}
+		if err := s.Flush(); err != nil {
+			return 0, fmt.Errorf("%s: %w", m.names[i], err)
+		}
+		if written > 0 {
+			log.Printf("[恢复] %s: 补写 %d 条记录", m.names[i], written)
+		}
+	}
+
+	if m.hasDeferred() {
+		m.mu.Lock()
+		for url := range pending {
+			m.deferred = append(m.deferred, url)
+		}
+		m.mu.Unlock()
+		return len(pending), nil
+	}
+	for url := range pending {
+		if err := store.AckRecord(url); err != nil {
+			return 0, err
+		}
+	}
+	return len(pending), nil
+}
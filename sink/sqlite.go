@@ -0,0 +1,254 @@
+package sink
+
+import (
+	"database/sql"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/yangbin1322/go-gsmarena/crawler"
+	_ "modernc.org/sqlite" // 纯 Go 实现的 SQLite 驱动
+)
+
+// sqliteSchema 规范化表结构
+//
+//	brands         品牌
+//	devices        设备（按设备 ID 去重），包含归一化后的数值字段
+//	spec_sections  规格参数分组（Network、Display...）
+//	spec_fields    设备的规格参数，按分组和页面顺序保存
+//	variants       设备型号代码（Misc -> Models，如 A2846）
+const sqliteSchema = `
+CREATE TABLE IF NOT EXISTS brands (
+	id   INTEGER PRIMARY KEY AUTOINCREMENT,
+	name TEXT NOT NULL UNIQUE
+);
+
+CREATE TABLE IF NOT EXISTS devices (
+	id                  TEXT PRIMARY KEY,
+	brand_id            INTEGER NOT NULL REFERENCES brands(id),
+	model_name          TEXT NOT NULL,
+	url                 TEXT NOT NULL UNIQUE,
+	release_date        TEXT,
+	release_year        INTEGER,
+	display_size_inches REAL,
+	display_width_px    INTEGER,
+	display_height_px   INTEGER,
+	battery_mah         INTEGER,
+	weight_grams        REAL,
+	ram_gb              REAL,
+	storage_gb          REAL,
+	price_eur           REAL,
+	has_5g              INTEGER NOT NULL DEFAULT 0,
+	crawled_at          TEXT
+);
+CREATE INDEX IF NOT EXISTS idx_devices_brand ON devices(brand_id);
+CREATE INDEX IF NOT EXISTS idx_devices_model_name ON devices(model_name);
+CREATE INDEX IF NOT EXISTS idx_devices_release_year ON devices(release_year);
+
+CREATE TABLE IF NOT EXISTS spec_sections (
+	id   INTEGER PRIMARY KEY AUTOINCREMENT,
+	name TEXT NOT NULL UNIQUE
+);
+
+CREATE TABLE IF NOT EXISTS spec_fields (
+	device_id  TEXT NOT NULL REFERENCES devices(id) ON DELETE CASCADE,
+	section_id INTEGER NOT NULL REFERENCES spec_sections(id),
+	position   INTEGER NOT NULL,
+	name       TEXT NOT NULL,
+	value      TEXT,
+	PRIMARY KEY (device_id, position)
+);
+CREATE INDEX IF NOT EXISTS idx_spec_fields_name ON spec_fields(section_id, name);
+
+CREATE TABLE IF NOT EXISTS variants (
+	device_id TEXT NOT NULL REFERENCES devices(id) ON DELETE CASCADE,
+	model     TEXT NOT NULL,
+	PRIMARY KEY (device_id, model)
+);
+CREATE INDEX IF NOT EXISTS idx_variants_model ON variants(model);
+`
+
+// SQLite 将手机数据写入 SQLite 数据库的规范化表中
+// 重复抓取同一设备时按设备 ID 覆盖旧数据（规格参数和型号整体替换），因此恢复时重复写入是安全的
+type SQLite struct {
+	db *sql.DB
+}
+
+// NewSQLite 打开（或创建）SQLite 数据库并初始化表结构
+func NewSQLite(path string) (*SQLite, error) {
+	// 外键约束需要在每个连接上开启
+	db, err := sql.Open("sqlite", path+"?_pragma=foreign_keys(1)")
+	if err != nil {
+		return nil, fmt.Errorf("打开 SQLite 数据库失败: %w", err)
+	}
+	// SQLite 同一时间只允许一个写连接
+	db.SetMaxOpenConns(1)
+
+	if _, err := db.Exec(sqliteSchema); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("初始化 SQLite 表结构失败: %w", err)
+	}
+	return &SQLite{db: db}, nil
+}
+
+// Write 在一个事务中写入（或覆盖）一台设备及其品牌、规格参数和型号
+func (s *SQLite) Write(phone crawler.Phone) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("开启 SQLite 事务失败: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := writeDevice(tx, phone); err != nil {
+		return fmt.Errorf("写入 SQLite 失败: %s: %w", phone.URL, err)
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("提交 SQLite 事务失败: %w", err)
+	}
+	return nil
+}
+
+// writeDevice 写入设备及其关联数据
+func writeDevice(tx *sql.Tx, phone crawler.Phone) error {
+	// URL 中没有设备 ID 时退化为按 URL 去重
+	deviceID := crawler.DeviceID(phone.URL)
+	if deviceID == "" {
+		deviceID = phone.URL
+	}
+
+	brandID, err := upsertName(tx, "brands", phone.Brand)
+	if err != nil {
+		return err
+	}
+
+	n := crawler.Normalize(phone)
+	_, err = tx.Exec(`
+		INSERT INTO devices (id, brand_id, model_name, url, release_date, release_year,
+			display_size_inches, display_width_px, display_height_px, battery_mah,
+			weight_grams, ram_gb, storage_gb, price_eur, has_5g, crawled_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT(id) DO UPDATE SET
+			brand_id = excluded.brand_id,
+			model_name = excluded.model_name,
+			url = excluded.url,
+			release_date = excluded.release_date,
+			release_year = excluded.release_year,
+			display_size_inches = excluded.display_size_inches,
+			display_width_px = excluded.display_width_px,
+			display_height_px = excluded.display_height_px,
+			battery_mah = excluded.battery_mah,
+			weight_grams = excluded.weight_grams,
+			ram_gb = excluded.ram_gb,
+			storage_gb = excluded.storage_gb,
+			price_eur = excluded.price_eur,
+			has_5g = excluded.has_5g,
+			crawled_at = excluded.crawled_at`,
+		deviceID, brandID, phone.ModelName, phone.URL, phone.ReleaseDate,
+		optionalInt(n.ReleaseYear), optionalFloat(n.DisplaySizeInches),
+		optionalInt(n.DisplayWidthPx), optionalInt(n.DisplayHeightPx),
+		optionalInt(n.BatteryMAh), optionalFloat(n.WeightGrams),
+		optionalFloat(n.RAMGB), optionalFloat(n.StorageGB), optionalFloat(n.PriceEUR),
+		n.Has5G, phone.CrawledAt)
+	if err != nil {
+		return fmt.Errorf("写入 devices 失败: %w", err)
+	}
+
+	// 规格参数和型号整体替换，避免旧数据残留
+	if _, err := tx.Exec(`DELETE FROM spec_fields WHERE device_id = ?`, deviceID); err != nil {
+		return fmt.Errorf("清理 spec_fields 失败: %w", err)
+	}
+	if _, err := tx.Exec(`DELETE FROM variants WHERE device_id = ?`, deviceID); err != nil {
+		return fmt.Errorf("清理 variants 失败: %w", err)
+	}
+
+	position := 0
+	for _, section := range sectionsOf(phone) {
+		sectionID, err := upsertName(tx, "spec_sections", section.Name)
+		if err != nil {
+			return err
+		}
+		for _, field := range section.Fields {
+			_, err := tx.Exec(`INSERT INTO spec_fields (device_id, section_id, position, name, value) VALUES (?, ?, ?, ?, ?)`,
+				deviceID, sectionID, position, field.Name, field.Value)
+			if err != nil {
+				return fmt.Errorf("写入 spec_fields 失败: %w", err)
+			}
+			position++
+		}
+	}
+
+	for _, model := range variantsOf(phone) {
+		_, err := tx.Exec(`INSERT OR IGNORE INTO variants (device_id, model) VALUES (?, ?)`, deviceID, model)
+		if err != nil {
+			return fmt.Errorf("写入 variants 失败: %w", err)
+		}
+	}
+	return nil
+}
+
+// upsertName 在 brands / spec_sections 这类 (id, name) 字典表中查找或插入名称，返回 id
+func upsertName(tx *sql.Tx, table, name string) (int64, error) {
+	if _, err := tx.Exec(`INSERT INTO `+table+` (name) VALUES (?) ON CONFLICT(name) DO NOTHING`, name); err != nil {
+		return 0, fmt.Errorf("写入 %s 失败: %w", table, err)
+	}
+	var id int64
+	if err := tx.QueryRow(`SELECT id FROM `+table+` WHERE name = ?`, name).Scan(&id); err != nil {
+		return 0, fmt.Errorf("查询 %s 失败: %w", table, err)
+	}
+	return id, nil
+}
+
+// sectionsOf 返回分组后的规格参数
+// 旧版本保存的记录没有分组信息，此时将 Specs 按名称排序后归入空分组
+func sectionsOf(phone crawler.Phone) []crawler.SpecSection {
+	if len(phone.Sections) > 0 {
+		return phone.Sections
+	}
+	if len(phone.Specs) == 0 {
+		return nil
+	}
+	names := make([]string, 0, len(phone.Specs))
+	for name := range phone.Specs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	section := crawler.SpecSection{Fields: make([]crawler.SpecField, 0, len(names))}
+	for _, name := range names {
+		section.Fields = append(section.Fields, crawler.SpecField{Name: name, Value: phone.Specs[name]})
+	}
+	return []crawler.SpecSection{section}
+}
+
+// variantsOf 解析 Misc 分组中的型号代码，如 "A2846, A3105, A3106"
+func variantsOf(phone crawler.Phone) []string {
+	models := phone.Specs["Models"]
+	for _, section := range phone.Sections {
+		if section.Name != "Misc" {
+			continue
+		}
+		for _, field := range section.Fields {
+			if field.Name == "Models" {
+				models = field.Value
+			}
+		}
+	}
+
+	var variants []string
+	for _, model := range strings.FieldsFunc(models, func(r rune) bool { return r == ',' || r == '\n' }) {
+		if model = strings.TrimSpace(model); model != "" {
+			variants = append(variants, model)
+		}
+	}
+	return variants
+}
+
+// Flush 每条记录独立提交，无需额外刷新
+func (s *SQLite) Flush() error {
+	return nil
+}
+
+// Close 关闭数据库连接
+func (s *SQLite) Close() error {
+	return s.db.Close()
+}
@@ -0,0 +1,47 @@
+package sink
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+
+	"github.com/yangbin1322/go-gsmarena/crawler"
+)
+
+// Stdout 将手机数据以 JSONL 格式写到标准输出（便于管道处理）
+type Stdout struct {
+	writer *bufio.Writer
+	mu     sync.Mutex
+}
+
+// NewStdout 创建标准输出
+func NewStdout() *Stdout {
+	return &Stdout{writer: bufio.NewWriter(os.Stdout)}
+}
+
+// Write 写入一条手机数据
+func (s *Stdout) Write(phone crawler.Phone) error {
+	data, err := json.Marshal(phone)
+	if err != nil {
+		return fmt.Errorf("JSON 序列化失败: %w", err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, err = s.writer.Write(append(data, '\n'))
+	return err
+}
+
+// Flush 将缓冲区写到标准输出
+func (s *Stdout) Flush() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.writer.Flush()
+}
+
+// Close 刷新缓冲区（不关闭标准输出本身）
+func (s *Stdout) Close() error {
+	return s.Flush()
+}
@@ -0,0 +1,149 @@
+package sink
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/yangbin1322/go-gsmarena/crawler"
+	"github.com/yangbin1322/go-gsmarena/storage"
+)
+
+// Webhook 请求头
+const (
+	// WebhookTimestampHeader 请求时间（Unix 秒），参与签名，接收方可据此拒绝重放
+	WebhookTimestampHeader = "X-Gsmarena-Timestamp"
+	// WebhookSignatureHeader 签名: "sha256=" + hex(HMAC-SHA256(secret, 时间戳 + "." + 请求体))
+	WebhookSignatureHeader = "X-Gsmarena-Signature"
+)
+
+// DefaultWebhookTimeout Webhook 单次请求超时时间
+const DefaultWebhookTimeout = 15 * time.Second
+
+// WebhookOptions Webhook 输出配置
+type WebhookOptions struct {
+	URL    string // 接收地址
+	Secret string // HMAC 签名密钥，为空时不签名
+
+	// Outbox 发件箱（BoltDB）文件路径，默认 webhook-outbox.db
+	Outbox string
+
+	BatchSize     int           // 每个请求最多包含的事件数，默认 DefaultBatchSize
+	FlushInterval time.Duration // 不足一批时的最长等待时间，默认 DefaultFlushInterval
+	MinBackoff    time.Duration // 首次重试等待时间，默认 DefaultMinBackoff
+	MaxBackoff    time.Duration // 最长重试等待时间，默认 DefaultMaxBackoff
+
+	// Client 发送请求使用的 HTTP 客户端，默认超时 DefaultWebhookTimeout
+	Client *http.Client
+}
+
+// Webhook 将新增或变化的手机数据以事件形式 POST 到 HTTP 接口
+//
+// 请求体为事件的 JSON 数组（见 Event），按批发送；2xx 视为投递成功，
+// 408 / 429 / 5xx 及网络错误退避重试，其他 4xx 视为永久失败移入死信队列。
+// 未投递的事件保存在本地发件箱中，重启后继续投递。
+type Webhook struct {
+	url      string
+	secret   []byte
+	client   *http.Client
+	delivery *delivery
+}
+
+// NewWebhook 创建 Webhook 输出并启动后台投递
+func NewWebhook(opts WebhookOptions) (*Webhook, error) {
+	if opts.URL == "" {
+		return nil, fmt.Errorf("Webhook 缺少接收地址")
+	}
+	if opts.Outbox == "" {
+		opts.Outbox = "webhook-outbox.db"
+	}
+	if opts.Client == nil {
+		opts.Client = &http.Client{Timeout: DefaultWebhookTimeout}
+	}
+
+	s := &Webhook{url: opts.URL, secret: []byte(opts.Secret), client: opts.Client}
+	d, err := newDelivery("Webhook", opts.Outbox, deliveryOptions{
+		BatchSize:     opts.BatchSize,
+		FlushInterval: opts.FlushInterval,
+		MinBackoff:    opts.MinBackoff,
+		MaxBackoff:    opts.MaxBackoff,
+	}, s.send)
+	if err != nil {
+		return nil, err
+	}
+	s.delivery = d
+	return s, nil
+}
+
+// Write 记录内容有变化时写入发件箱，由后台批量投递
+func (s *Webhook) Write(phone crawler.Phone) error {
+	return s.delivery.enqueue(phone)
+}
+
+// Flush 满一批时通知后台立即投递
+func (s *Webhook) Flush() error {
+	s.delivery.wake()
+	return nil
+}
+
+// Close 停止后台投递，尽量发送剩余事件后关闭发件箱
+func (s *Webhook) Close() error {
+	return s.delivery.close()
+}
+
+// send 发送一批事件
+func (s *Webhook) send(ctx context.Context, items []storage.OutboxItem) error {
+	// 发件箱中保存的就是事件 JSON，直接拼接为数组
+	var body bytes.Buffer
+	body.WriteByte('[')
+	for i, item := range items {
+		if i > 0 {
+			body.WriteByte(',')
+		}
+		body.Write(item.Data)
+	}
+	body.WriteByte(']')
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.url, bytes.NewReader(body.Bytes()))
+	if err != nil {
+		return permanentError{fmt.Errorf("创建请求失败: %w", err)}
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if len(s.secret) > 0 {
+		timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+		req.Header.Set(WebhookTimestampHeader, timestamp)
+		req.Header.Set(WebhookSignatureHeader, "sha256="+Sign(s.secret, timestamp, body.Bytes()))
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("请求失败: %w", err)
+	}
+	defer resp.Body.Close()
+	msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+
+	switch {
+	case resp.StatusCode >= 200 && resp.StatusCode < 300:
+		return nil
+	case resp.StatusCode == http.StatusRequestTimeout || resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500:
+		return fmt.Errorf("HTTP %d: %s", resp.StatusCode, bytes.TrimSpace(msg))
+	default:
+		return permanentError{fmt.Errorf("HTTP %d: %s", resp.StatusCode, bytes.TrimSpace(msg))}
+	}
+}
+
+// Sign 计算 Webhook 签名（hex 编码），接收方用相同的方法校验
+func Sign(secret []byte, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte{'.'})
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
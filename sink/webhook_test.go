@@ -0,0 +1,246 @@
+package sink
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/yangbin1322/go-gsmarena/crawler"
+)
+
+// webhookRequest 测试服务器收到的一次请求
+type webhookRequest struct {
+	header http.Header
+	body   []byte
+	events []Event
+}
+
+// webhookServer 记录请求的测试服务器，status 依次返回，用完后返回 200
+type webhookServer struct {
+	*httptest.Server
+	mu       sync.Mutex
+	status   []int
+	requests []webhookRequest
+}
+
+func newWebhookServer(t *testing.T, status ...int) *webhookServer {
+	s := &webhookServer{status: status}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		var events []Event
+		if err := json.Unmarshal(body, &events); err != nil {
+			t.Errorf("请求体不是事件数组: %v", err)
+		}
+
+		s.mu.Lock()
+		s.requests = append(s.requests, webhookRequest{header: r.Header.Clone(), body: body, events: events})
+		code := http.StatusOK
+		if len(s.status) > 0 {
+			code, s.status = s.status[0], s.status[1:]
+		}
+		s.mu.Unlock()
+		w.WriteHeader(code)
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+// received 返回收到的请求副本
+func (s *webhookServer) received() []webhookRequest {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]webhookRequest(nil), s.requests...)
+}
+
+// testPhone 构造测试用的手机数据
+func testPhone(id, size string) crawler.Phone {
+	return crawler.Phone{
+		SchemaVersion: crawler.SchemaVersion,
+		ModelName:     "Phone " + id,
+		Brand:         "Test",
+		URL:           "https://www.gsmarena.com/test_phone-" + id + ".php",
+		Specs:         map[string]string{"Size": size},
+		CrawledAt:     time.Now().Format(time.RFC3339),
+	}
+}
+
+// waitFor 等待条件成立，超时后测试失败
+func waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("等待超时: %s", what)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// writeAll 写入并刷新多条记录
+func writeAll(t *testing.T, s Sink, phones ...crawler.Phone) {
+	t.Helper()
+	for _, phone := range phones {
+		if err := s.Write(phone); err != nil {
+			t.Fatalf("写入失败: %v", err)
+		}
+		if err := s.Flush(); err != nil {
+			t.Fatalf("刷新失败: %v", err)
+		}
+	}
+}
+
+func TestWebhookBatchesAndSigns(t *testing.T) {
+	srv := newWebhookServer(t)
+	secret := "s3cret"
+	w, err := NewWebhook(WebhookOptions{
+		URL:           srv.URL,
+		Secret:        secret,
+		Outbox:        filepath.Join(t.TempDir(), "outbox.db"),
+		BatchSize:     2,
+		FlushInterval: time.Hour, // 只按批次大小投递
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 内容未变化的记录不重复入队，变化的记录产生 updated 事件
+	writeAll(t, w, testPhone("1", "6.1"), testPhone("1", "6.1"), testPhone("2", "6.7"))
+	waitFor(t, "第一批投递", func() bool { return len(srv.received()) == 1 })
+	writeAll(t, w, testPhone("1", "6.3"))
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	requests := srv.received()
+	if len(requests) != 2 {
+		t.Fatalf("请求数 = %d，期望 2", len(requests))
+	}
+	if got := len(requests[0].events); got != 2 {
+		t.Fatalf("第一批事件数 = %d，期望 2", got)
+	}
+	for i, want := range []struct{ typ, device string }{
+		{EventCreated, "1"}, {EventCreated, "2"},
+	} {
+		if e := requests[0].events[i]; e.Type != want.typ || e.DeviceID != want.device {
+			t.Errorf("事件 %d = %s/%s，期望 %s/%s", i, e.Type, e.DeviceID, want.typ, want.device)
+		}
+	}
+	if e := requests[1].events; len(e) != 1 || e[0].Type != EventUpdated || e[0].Phone.Specs["Size"] != "6.3" {
+		t.Errorf("第二批事件 = %+v，期望设备 1 的 updated 事件", e)
+	}
+
+	for _, r := range requests {
+		timestamp := r.header.Get(WebhookTimestampHeader)
+		if timestamp == "" {
+			t.Fatal("缺少时间戳请求头")
+		}
+		want := "sha256=" + Sign([]byte(secret), timestamp, r.body)
+		if got := r.header.Get(WebhookSignatureHeader); got != want {
+			t.Errorf("签名 = %s，期望 %s", got, want)
+		}
+	}
+}
+
+func TestWebhookRetriesUntilAck(t *testing.T) {
+	srv := newWebhookServer(t, http.StatusServiceUnavailable, http.StatusTooManyRequests)
+	w, err := NewWebhook(WebhookOptions{
+		URL:           srv.URL,
+		Outbox:        filepath.Join(t.TempDir(), "outbox.db"),
+		BatchSize:     1,
+		FlushInterval: time.Hour,
+		MinBackoff:    10 * time.Millisecond,
+		MaxBackoff:    20 * time.Millisecond,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+
+	writeAll(t, w, testPhone("1", "6.1"))
+	waitFor(t, "重试后投递成功", func() bool { return w.delivery.outbox.Len() == 0 })
+
+	if got := len(srv.received()); got != 3 {
+		t.Errorf("请求数 = %d，期望 3（两次失败后成功）", got)
+	}
+	w.delivery.mu.Lock()
+	delivered, dead := w.delivery.delivered, w.delivery.dead
+	w.delivery.mu.Unlock()
+	if delivered != 1 || dead != 0 {
+		t.Errorf("投递 %d / 死信 %d，期望 1 / 0", delivered, dead)
+	}
+}
+
+func TestWebhookDeadLettersClientErrors(t *testing.T) {
+	srv := newWebhookServer(t, http.StatusBadRequest)
+	w, err := NewWebhook(WebhookOptions{
+		URL:           srv.URL,
+		Outbox:        filepath.Join(t.TempDir(), "outbox.db"),
+		BatchSize:     2,
+		FlushInterval: time.Hour,
+		MinBackoff:    10 * time.Millisecond,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+
+	writeAll(t, w, testPhone("1", "6.1"), testPhone("2", "6.7"))
+	waitFor(t, "移入死信队列", func() bool {
+		w.delivery.mu.Lock()
+		defer w.delivery.mu.Unlock()
+		return w.delivery.dead == 2
+	})
+
+	if got := w.delivery.outbox.Len(); got != 0 {
+		t.Errorf("发件箱剩余 %d 条，期望 0", got)
+	}
+	if got := len(srv.received()); got != 1 {
+		t.Errorf("请求数 = %d，期望 1（4xx 不重试）", got)
+	}
+
+	// 死信清除了设备的哈希，再次抓取到相同内容时重新入队
+	writeAll(t, w, testPhone("1", "6.1"))
+	if got := w.delivery.outbox.Len(); got != 1 {
+		t.Errorf("死信设备再次写入后发件箱 %d 条，期望 1", got)
+	}
+}
+
+func TestWebhookReplaysOutboxAfterReopen(t *testing.T) {
+	outbox := filepath.Join(t.TempDir(), "outbox.db")
+	down := newWebhookServer(t, http.StatusServiceUnavailable, http.StatusServiceUnavailable, http.StatusServiceUnavailable)
+
+	w, err := NewWebhook(WebhookOptions{URL: down.URL, Outbox: outbox, BatchSize: 10, FlushInterval: time.Hour})
+	if err != nil {
+		t.Fatal(err)
+	}
+	writeAll(t, w, testPhone("1", "6.1"), testPhone("2", "6.7"))
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	srv := newWebhookServer(t)
+	w, err = NewWebhook(WebhookOptions{URL: srv.URL, Outbox: outbox, BatchSize: 10, FlushInterval: 20 * time.Millisecond})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+	if got := w.delivery.outbox.Len(); got != 2 {
+		t.Fatalf("重新打开后发件箱 %d 条，期望 2", got)
+	}
+	waitFor(t, "重新打开后投递", func() bool { return w.delivery.outbox.Len() == 0 })
+
+	var devices []string
+	for _, r := range srv.received() {
+		for _, e := range r.events {
+			devices = append(devices, e.DeviceID)
+		}
+	}
+	if len(devices) != 2 || devices[0] != "1" || devices[1] != "2" {
+		t.Errorf("补发的设备 = %v，期望按入队顺序 [1 2]", devices)
+	}
+}
@@ -0,0 +1,31 @@
+package storage
+
+import "sync"
+
+// MemoryStorage 基于内存的存储实现
+// 进程退出后数据丢失，适用于嵌入调用或一次性抓取（不需要断点续传）
+type MemoryStorage struct {
+	visited sync.Map // map[string]struct{}
+}
+
+// NewMemoryStorage 创建新的内存存储实例
+func NewMemoryStorage() *MemoryStorage {
+	return &MemoryStorage{}
+}
+
+// IsVisited 检查 URL 是否已被访问过
+func (s *MemoryStorage) IsVisited(url string) bool {
+	_, ok := s.visited.Load(url)
+	return ok
+}
+
+// MarkVisited 将 URL 标记为已访问
+func (s *MemoryStorage) MarkVisited(url string) error {
+	s.visited.Store(url, struct{}{})
+	return nil
+}
+
+// Close 内存存储无需释放资源
+func (s *MemoryStorage) Close() error {
+	return nil
+}
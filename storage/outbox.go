@@ -0,0 +1,179 @@
+package storage
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// 发件箱相关的 Bucket 名称
+var (
+	// outboxBucket 待投递的消息 (Key=序号, Value=设备键 + '\n' + 消息)
+	outboxBucket = []byte("outbox")
+	// outboxHashBucket 每个设备最近一次入队内容的哈希 (Key=设备键)，用于只投递新增或变化的记录
+	outboxHashBucket = []byte("outbox_hashes")
+	// deadLetterBucket 无法投递的消息（对端永久拒绝），保留以便人工排查
+	deadLetterBucket = []byte("dead_letter")
+)
+
+// OutboxItem 发件箱中的一条消息
+type OutboxItem struct {
+	Seq  uint64 // 入队序号（单调递增）
+	Key  string // 设备键
+	Data []byte // 消息内容
+}
+
+// Outbox 基于 BoltDB 的发件箱（本地持久化队列）
+// 消息先写入磁盘再异步投递，只有对端确认后才删除，进程崩溃或重启后继续投递（至少一次）
+type Outbox struct {
+	db *bolt.DB
+}
+
+// NewOutbox 打开（或创建）发件箱数据库
+func NewOutbox(path string) (*Outbox, error) {
+	db, err := bolt.Open(path, 0600, &bolt.Options{Timeout: 3 * time.Second})
+	if err != nil {
+		return nil, fmt.Errorf("无法打开发件箱数据库: %w", err)
+	}
+	err = db.Update(func(tx *bolt.Tx) error {
+		for _, name := range [][]byte{outboxBucket, outboxHashBucket, deadLetterBucket} {
+			if _, err := tx.CreateBucketIfNotExists(name); err != nil {
+				return fmt.Errorf("创建 Bucket 失败: %w", err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+	return &Outbox{db: db}, nil
+}
+
+// Enqueue 内容有变化时将消息入队
+// hash 与该设备上次入队的哈希相同时不入队并返回 false；
+// build 的参数表示该设备之前是否入队过（用于区分新增和更新事件）
+func (o *Outbox) Enqueue(key string, hash []byte, build func(existed bool) ([]byte, error)) (bool, error) {
+	enqueued := false
+	err := o.db.Update(func(tx *bolt.Tx) error {
+		hashes := tx.Bucket(outboxHashBucket)
+		previous := hashes.Get([]byte(key))
+		if previous != nil && bytes.Equal(previous, hash) {
+			return nil
+		}
+
+		data, err := build(previous != nil)
+		if err != nil {
+			return err
+		}
+
+		queue := tx.Bucket(outboxBucket)
+		seq, err := queue.NextSequence()
+		if err != nil {
+			return err
+		}
+		value := append(append([]byte(key), '\n'), data...)
+		if err := queue.Put(seqKey(seq), value); err != nil {
+			return err
+		}
+		enqueued = true
+		return hashes.Put([]byte(key), hash)
+	})
+	if err != nil {
+		return false, fmt.Errorf("消息入队失败: %w", err)
+	}
+	return enqueued, nil
+}
+
+// Peek 按入队顺序返回最多 n 条待投递的消息（不删除）
+func (o *Outbox) Peek(n int) ([]OutboxItem, error) {
+	var items []OutboxItem
+	err := o.db.View(func(tx *bolt.Tx) error {
+		c := tx.Bucket(outboxBucket).Cursor()
+		for k, v := c.First(); k != nil && len(items) < n; k, v = c.Next() {
+			key, data, _ := bytes.Cut(v, []byte{'\n'})
+			items = append(items, OutboxItem{
+				Seq:  binary.BigEndian.Uint64(k),
+				Key:  string(key),
+				Data: bytes.Clone(data),
+			})
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("读取发件箱失败: %w", err)
+	}
+	return items, nil
+}
+
+// Ack 消息已投递，从发件箱删除
+func (o *Outbox) Ack(items ...OutboxItem) error {
+	err := o.db.Update(func(tx *bolt.Tx) error {
+		queue := tx.Bucket(outboxBucket)
+		for _, item := range items {
+			if err := queue.Delete(seqKey(item.Seq)); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("删除已投递消息失败: %w", err)
+	}
+	return nil
+}
+
+// DeadLetter 将无法投递的消息移入死信队列
+// 同时清除设备的哈希，该设备下次被抓取时会重新入队
+func (o *Outbox) DeadLetter(items ...OutboxItem) error {
+	err := o.db.Update(func(tx *bolt.Tx) error {
+		queue := tx.Bucket(outboxBucket)
+		dead := tx.Bucket(deadLetterBucket)
+		hashes := tx.Bucket(outboxHashBucket)
+		for _, item := range items {
+			value := queue.Get(seqKey(item.Seq))
+			if value == nil {
+				continue
+			}
+			if err := dead.Put(seqKey(item.Seq), value); err != nil {
+				return err
+			}
+			if err := queue.Delete(seqKey(item.Seq)); err != nil {
+				return err
+			}
+			if err := hashes.Delete([]byte(item.Key)); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("移入死信队列失败: %w", err)
+	}
+	return nil
+}
+
+// Len 返回待投递的消息数量
+func (o *Outbox) Len() int {
+	n := 0
+	o.db.View(func(tx *bolt.Tx) error {
+		n = tx.Bucket(outboxBucket).Stats().KeyN
+		return nil
+	})
+	return n
+}
+
+// Close 关闭发件箱数据库
+func (o *Outbox) Close() error {
+	return o.db.Close()
+}
+
+// seqKey 序号按大端编码，保证 BoltDB 中按入队顺序排列
+func seqKey(seq uint64) []byte {
+	key := make([]byte, 8)
+	binary.BigEndian.PutUint64(key, seq)
+	return key
+}
@@ -0,0 +1,83 @@
+package storage
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// proxiesBucket 保存代理池快照 (Key=代理地址的哈希, Value=代理状态，键和值的格式由 proxy 包决定)
+var proxiesBucket = []byte("proxy_pool")
+
+// SaveProxies 用新的快照替换保存的代理池（实现 proxy.PoolStore）
+func (s *BoltStorage) SaveProxies(pool map[string][]byte) error {
+	err := s.db.Update(func(tx *bolt.Tx) error {
+		// 重建 Bucket，已移出代理池的代理不再保留
+		if err := tx.DeleteBucket(proxiesBucket); err != nil && !errors.Is(err, bolt.ErrBucketNotFound) {
+			return err
+		}
+		b, err := tx.CreateBucket(proxiesBucket)
+		if err != nil {
+			return err
+		}
+		for key, data := range pool {
+			if err := b.Put([]byte(key), data); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("保存代理池失败: %w", err)
+	}
+	return nil
+}
+
+// LoadProxies 遍历保存的代理池（实现 proxy.PoolStore）
+// fn 在只读事务中执行，data 仅在回调内有效
+func (s *BoltStorage) LoadProxies(fn func(key string, data []byte) error) error {
+	return s.db.View(func(tx *bolt.Tx) error {
+		b := tx.Bucket(proxiesBucket)
+		if b == nil {
+			return nil
+		}
+		return b.ForEach(func(k, v []byte) error {
+			return fn(string(k), v)
+		})
+	})
+}
+
+// budgetBucket 保存代理 API 调用预算的状态 (Key=budgetKey，格式由 proxy 包决定)
+var (
+	budgetBucket = []byte("proxy_budget")
+	budgetKey    = []byte("state")
+)
+
+// SaveBudget 保存代理 API 调用预算的状态（实现 proxy.BudgetStore）
+func (s *BoltStorage) SaveBudget(data []byte) error {
+	err := s.db.Update(func(tx *bolt.Tx) error {
+		b, err := tx.CreateBucketIfNotExists(budgetBucket)
+		if err != nil {
+			return err
+		}
+		return b.Put(budgetKey, data)
+	})
+	if err != nil {
+		return fmt.Errorf("保存代理预算失败: %w", err)
+	}
+	return nil
+}
+
+// LoadBudget 读取保存的代理 API 调用预算状态，未保存过时返回 nil（实现 proxy.BudgetStore）
+func (s *BoltStorage) LoadBudget() ([]byte, error) {
+	var data []byte
+	err := s.db.View(func(tx *bolt.Tx) error {
+		if b := tx.Bucket(budgetBucket); b != nil {
+			data = bytes.Clone(b.Get(budgetKey))
+		}
+		return nil
+	})
+	return data, err
+}
@@ -0,0 +1,86 @@
+package storage
+
+import (
+	"fmt"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// 抓取记录相关的 Bucket 名称
+var (
+	// recordsBucket 保存解析后的记录 (Key=URL, Value=JSON)
+	recordsBucket = []byte("records")
+	// pendingBucket 已提交但尚未导出到输出文件的记录 (Key=URL)
+	pendingBucket = []byte("pending_export")
+)
+
+// RecordStore 支持事务性保存抓取记录的存储
+// 记录、已访问标记和待导出标记在同一事务中提交，输出文件由记录派生：
+// 导出成功后调用 AckRecord 清除待导出标记，崩溃后可通过 PendingRecords 补齐
+type RecordStore interface {
+	Storage
+	// SaveRecord 在同一事务中保存记录并标记 URL 为已访问
+	SaveRecord(url string, record []byte) error
+	// AckRecord 记录已成功导出，清除待导出标记
+	AckRecord(url string) error
+	// PendingRecords 遍历已提交但尚未导出的记录
+	PendingRecords(fn func(url string, record []byte) error) error
+	// ForEachRecord 遍历所有已保存的记录
+	ForEachRecord(fn func(url string, record []byte) error) error
+}
+
+// SaveRecord 在同一事务中保存记录、标记已访问并加入待导出队列
+func (s *BoltStorage) SaveRecord(url string, record []byte) error {
+	err := s.db.Update(func(tx *bolt.Tx) error {
+		key := []byte(url)
+		if err := tx.Bucket(recordsBucket).Put(key, record); err != nil {
+			return err
+		}
+		if err := tx.Bucket(pendingBucket).Put(key, []byte{}); err != nil {
+			return err
+		}
+		timestamp := []byte(time.Now().Format(time.RFC3339))
+		return tx.Bucket(s.bucketName).Put(key, timestamp)
+	})
+	if err != nil {
+		return fmt.Errorf("保存记录失败: %w", err)
+	}
+	return nil
+}
+
+// AckRecord 清除记录的待导出标记
+func (s *BoltStorage) AckRecord(url string) error {
+	err := s.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(pendingBucket).Delete([]byte(url))
+	})
+	if err != nil {
+		return fmt.Errorf("清除待导出标记失败: %w", err)
+	}
+	return nil
+}
+
+// PendingRecords 遍历已提交但尚未导出的记录
+// fn 在只读事务中执行，不能在其中调用 AckRecord 等写操作
+func (s *BoltStorage) PendingRecords(fn func(url string, record []byte) error) error {
+	return s.db.View(func(tx *bolt.Tx) error {
+		records := tx.Bucket(recordsBucket)
+		return tx.Bucket(pendingBucket).ForEach(func(k, _ []byte) error {
+			record := records.Get(k)
+			if record == nil {
+				return nil
+			}
+			return fn(string(k), record)
+		})
+	})
+}
+
+// ForEachRecord 遍历所有已保存的记录
+// fn 在只读事务中执行，record 仅在回调内有效
+func (s *BoltStorage) ForEachRecord(fn func(url string, record []byte) error) error {
+	return s.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(recordsBucket).ForEach(func(k, v []byte) error {
+			return fn(string(k), v)
+		})
+	})
+}
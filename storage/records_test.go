@@ -0,0 +1,94 @@
+package storage
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+// openBolt 打开测试数据库
+func openBolt(t *testing.T, path string) *BoltStorage {
+	t.Helper()
+	s, err := NewBoltStorage(path, "visited")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+// pending 返回待导出的记录
+func pending(t *testing.T, s *BoltStorage) map[string]string {
+	t.Helper()
+	out := map[string]string{}
+	err := s.PendingRecords(func(url string, record []byte) error {
+		out[url] = string(record)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return out
+}
+
+func TestSaveRecordSurvivesRestartUntilAck(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "crawler.db")
+	const url = "https://www.gsmarena.com/test_phone-1.php"
+
+	// 保存后未确认即"崩溃"
+	s := openBolt(t, path)
+	if err := s.SaveRecord(url, []byte(`{"model_name":"Phone 1"}`)); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// 重启后记录、已访问标记和待导出标记都在
+	s = openBolt(t, path)
+	if !s.IsVisited(url) {
+		t.Error("重启后 URL 未标记为已访问")
+	}
+	if got := pending(t, s); len(got) != 1 || got[url] != `{"model_name":"Phone 1"}` {
+		t.Fatalf("重启后待导出记录 = %v，期望 1 条", got)
+	}
+
+	// 确认后不再待导出，记录本身保留
+	if err := s.AckRecord(url); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Close(); err != nil {
+		t.Fatal(err)
+	}
+	s = openBolt(t, path)
+	defer s.Close()
+	if got := pending(t, s); len(got) != 0 {
+		t.Errorf("确认后待导出记录 = %v，期望为空", got)
+	}
+	records := 0
+	err := s.ForEachRecord(func(string, []byte) error {
+		records++
+		return nil
+	})
+	if err != nil || records != 1 {
+		t.Errorf("记录数 = %d (%v)，期望 1", records, err)
+	}
+}
+
+func TestSaveRecordOverwritesAndRequeues(t *testing.T) {
+	s := openBolt(t, filepath.Join(t.TempDir(), "crawler.db"))
+	defer s.Close()
+	const url = "https://www.gsmarena.com/test_phone-1.php"
+
+	if err := s.SaveRecord(url, []byte(`{"v":1}`)); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.AckRecord(url); err != nil {
+		t.Fatal(err)
+	}
+	// 重新抓取时覆盖记录并重新加入待导出队列
+	if err := s.SaveRecord(url, []byte(`{"v":2}`)); err != nil {
+		t.Fatal(err)
+	}
+	if got := pending(t, s); len(got) != 1 || got[url] != `{"v":2}` {
+		t.Errorf("待导出记录 = %v，期望最新版本", got)
+	}
+}
@@ -1,4 +1,5 @@
-package main
+// Package storage 提供爬虫的持久化存储（URL 去重、断点续传）
+package storage
 
 import (
 	"fmt"
@@ -43,9 +44,10 @@ func NewBoltStorage(dbPath, bucketName string) (*BoltStorage, error) {
 
 	// 初始化 Bucket (如果不存在则创建)
 	err = db.Update(func(tx *bolt.Tx) error {
-		_, err := tx.CreateBucketIfNotExists(storage.bucketName)
-		if err != nil {
-			return fmt.Errorf("创建 Bucket 失败: %w", err)
+		for _, name := range [][]byte{storage.bucketName, recordsBucket, pendingBucket, proxiesBucket} {
+			if _, err := tx.CreateBucketIfNotExists(name); err != nil {
+				return fmt.Errorf("创建 Bucket 失败: %w", err)
+			}
 		}
 		return nil
 	})